@@ -27,7 +27,7 @@ resource "proxmox_vm_qemu" "cloudinit-test" {
     # Setup the disk. The id has to be unique
     disk {
         id = 0
-        size = 32
+        size = "32G"
         type = "virtio"
         storage = "ceph-storage-pool"
         storage_type = "rbd"
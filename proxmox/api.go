@@ -0,0 +1,248 @@
+package proxmox
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	pxapi "github.com/3coma3/proxmox-api-go/proxmox"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+// Access to api endpoints that pxapi doesn't wrap in an object yet. The
+// requests go through the current client (see Client.Set), and requests that
+// start a task wait for it to finish like the pxapi.Vm methods do.
+
+// return the data member of a GET response
+func apiGet(url string) (interface{}, error) {
+	resp, err := apiGetResponse(url)
+	if err != nil {
+		return nil, err
+	}
+	return resp["data"], nil
+}
+
+// return a whole GET response, for the endpoints that send more than data
+func apiGetResponse(url string) (resp map[string]interface{}, err error) {
+	err = pxapi.GetClient().GetJsonRetryable(url, &resp, 3)
+	return
+}
+
+func apiGetMap(url string) (map[string]interface{}, error) {
+	data, err := apiGet(url)
+	if err != nil {
+		return nil, err
+	}
+	dataMap, _ := data.(map[string]interface{})
+	return dataMap, nil
+}
+
+func apiGetList(url string) ([]interface{}, error) {
+	data, err := apiGet(url)
+	if err != nil {
+		return nil, err
+	}
+	dataList, _ := data.([]interface{})
+	return dataList, nil
+}
+
+// return the item of a listing whose key has the given value, or nil when
+// there's none, which is how resources deleted outside of terraform show
+func apiFindInList(url string, key string, value interface{}) (map[string]interface{}, error) {
+	list, err := apiGetList(url)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range list {
+		if item, isMap := v.(map[string]interface{}); isMap && item[key] == value {
+			return item, nil
+		}
+	}
+	return nil, nil
+}
+
+func apiPost(url string, params map[string]interface{}) (interface{}, error) {
+	return apiWait(pxapi.GetClient().Post(url, params))
+}
+
+func apiPut(url string, params map[string]interface{}) (interface{}, error) {
+	return apiWait(pxapi.GetClient().Put(url, params))
+}
+
+func apiDelete(url string, params map[string]interface{}) (interface{}, error) {
+	return apiWait(pxapi.GetClient().Delete(url, params))
+}
+
+// wait for the task in a response if there is one, and return the task exit
+// status or the response data otherwise
+func apiWait(resp map[string]interface{}, err error) (interface{}, error) {
+	if err != nil {
+		return nil, err
+	}
+	if upid, isTask := resp["data"].(string); isTask && strings.HasPrefix(upid, "UPID:") {
+		return pxapi.GetClient().WaitForCompletion(resp)
+	}
+	return resp["data"], nil
+}
+
+func vmApiPath(vm *pxapi.Vm) string {
+	return fmt.Sprintf("/nodes/%s/%s/%d", vm.Node().Name(), vm.Type(), vm.Id())
+}
+
+func vmRunning(vm *pxapi.Vm) (bool, error) {
+	status, err := apiGetMap(vmApiPath(vm) + "/status/current")
+	if err != nil {
+		return false, err
+	}
+	return status["status"] == "running", nil
+}
+
+// wait for a VM to be stopped, as it can still be going down when the stop
+// task ends
+func vmWaitStopped(vm *pxapi.Vm) error {
+	for i := 0; i < 30; i++ {
+		running, err := vmRunning(vm)
+		if err != nil || !running {
+			return err
+		}
+		time.Sleep(time.Second)
+	}
+	return fmt.Errorf("Timed out waiting for guest %d to stop", vm.Id())
+}
+
+// numbers and flags come as json numbers or as strings depending on the
+// endpoint
+func apiInt(v interface{}) int {
+	switch v := v.(type) {
+	case float64:
+		return int(v)
+	case int:
+		return v
+	case string:
+		i, _ := strconv.Atoi(v)
+		return i
+	case bool:
+		if v {
+			return 1
+		}
+	}
+	return 0
+}
+
+func apiBool(v interface{}) bool {
+	return apiInt(v) != 0
+}
+
+// lists come as json arrays or as strings separated by commas or spaces
+func apiStringList(v interface{}) (list []string) {
+	switch v := v.(type) {
+	case []interface{}:
+		for _, item := range v {
+			list = append(list, fmt.Sprint(item))
+		}
+	case string:
+		list = strings.FieldsFunc(v, func(r rune) bool {
+			return r == ',' || r == ' ' || r == ';'
+		})
+	}
+	return
+}
+
+// Build the api parameters for the attributes in fields, which maps attribute
+// names to api ones. Lists and sets are joined with listSeparator. Empty
+// attributes, false ones included, are left out as proxmox rejects options
+// that don't apply. On updates only the changed attributes are sent, the
+// emptied ones being listed in delete.
+func apiParams(
+	d *schema.ResourceData,
+	fields map[string]string,
+	listSeparator string,
+	update bool,
+) map[string]interface{} {
+	var (
+		params  = map[string]interface{}{}
+		deleted []string
+	)
+
+	for attr, param := range fields {
+		var value interface{}
+
+		if update && !d.HasChange(attr) {
+			continue
+		}
+
+		switch v := d.Get(attr).(type) {
+		case bool:
+			if v {
+				value = 1
+			}
+		case int:
+			if v != 0 {
+				value = v
+			}
+		case string:
+			if v != "" {
+				value = v
+			}
+		case []interface{}, *schema.Set:
+			var items []string
+			if set, isSet := v.(*schema.Set); isSet {
+				for _, item := range set.List() {
+					items = append(items, item.(string))
+				}
+				sort.Strings(items)
+			} else {
+				for _, item := range v.([]interface{}) {
+					items = append(items, item.(string))
+				}
+			}
+			if len(items) > 0 {
+				value = strings.Join(items, listSeparator)
+			}
+		}
+
+		if value != nil {
+			params[param] = value
+		} else if update {
+			deleted = append(deleted, param)
+		}
+	}
+
+	if len(deleted) > 0 {
+		sort.Strings(deleted)
+		params["delete"] = strings.Join(deleted, ",")
+	}
+	return params
+}
+
+// set the attributes in fields from the api values in conf, converting them
+// to the schema types
+func setApiFields(
+	d *schema.ResourceData,
+	resourceSchema map[string]*schema.Schema,
+	fields map[string]string,
+	conf map[string]interface{},
+) (err error) {
+	for attr, param := range fields {
+		value := conf[param]
+		valueString, _ := value.(string)
+
+		switch resourceSchema[attr].Type {
+		case schema.TypeBool:
+			err = d.Set(attr, apiBool(value))
+		case schema.TypeInt:
+			err = d.Set(attr, apiInt(value))
+		case schema.TypeList, schema.TypeSet:
+			err = d.Set(attr, apiStringList(value))
+		default:
+			err = d.Set(attr, strings.TrimSpace(valueString))
+		}
+
+		if err != nil {
+			return
+		}
+	}
+	return
+}
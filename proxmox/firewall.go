@@ -0,0 +1,106 @@
+package proxmox
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+// The firewall resources apply to the cluster, to a node or to a guest, as
+// given by their node and vm attributes, neither of them meaning the cluster.
+// Scopes are identified as "cluster", "node/<node>" or by the id of the guest
+// resource, as in node/qemu/100.
+
+var firewallActions = []string{"ACCEPT", "DROP", "REJECT"}
+
+var firewallLogLevels = []string{"emerg", "alert", "crit", "err", "warning", "notice", "info", "debug", "nolog"}
+
+// proxmox keeps the names of ipsets, aliases and groups in lowercase
+var rxFirewallName = regexp.MustCompile("^[a-z][a-z0-9_-]+$")
+
+// add the scope attributes to a firewall resource schema. ipsets and aliases
+// don't exist at the node level, so nodeScope is false for them.
+func firewallScopeSchema(resourceSchema map[string]*schema.Schema, nodeScope bool) map[string]*schema.Schema {
+	resourceSchema["vm"] = &schema.Schema{
+		Type:             schema.TypeString,
+		Optional:         true,
+		ForceNew:         true,
+		Description:      "Id of a proxmox_vm_qemu or proxmox_vm_lxc, as in node/qemu/100",
+		DiffSuppressFunc: resourceIdDiffSuppress,
+	}
+
+	if nodeScope {
+		resourceSchema["vm"].ConflictsWith = []string{"node"}
+		resourceSchema["node"] = &schema.Schema{
+			Type:          schema.TypeString,
+			Optional:      true,
+			ForceNew:      true,
+			ConflictsWith: []string{"vm"},
+		}
+	}
+	return resourceSchema
+}
+
+func firewallScopeId(d *schema.ResourceData) string {
+	if vm := d.Get("vm").(string); vm != "" {
+		return vm
+	}
+	if node, _ := d.Get("node").(string); node != "" {
+		return "node/" + node
+	}
+	return "cluster"
+}
+
+// set the scope attributes back from the id, for imports
+func setFirewallScope(d *schema.ResourceData, scopeId string) {
+	switch {
+	case scopeId == "cluster":
+	case strings.HasPrefix(scopeId, "node/"):
+		d.Set("node", strings.TrimPrefix(scopeId, "node/"))
+	default:
+		d.Set("vm", scopeId)
+	}
+}
+
+func firewallApiPath(scopeId string) (string, error) {
+	idParts := strings.Split(scopeId, "/")
+
+	if scopeId == "cluster" {
+		return "/cluster/firewall", nil
+	}
+	if len(idParts) == 2 && idParts[0] == "node" && idParts[1] != "" {
+		return fmt.Sprintf("/nodes/%s/firewall", idParts[1]), nil
+	}
+
+	targetNode, vmType, vmId, err := parseResourceId(scopeId)
+	if err != nil || len(idParts) != 3 {
+		return "", fmt.Errorf("Invalid firewall scope: %s. Must be cluster, node/<node> or node/type/vmId", scopeId)
+	}
+
+	// guests are reached on the node they are on now, as they may have
+	// migrated since the scope was set
+	vmResId, err := findResourceId(vmId)
+	if err != nil {
+		return "", err
+	}
+	if vmResId != "" {
+		targetNode, _, _, _ = parseResourceId(vmResId)
+	}
+	return fmt.Sprintf("/nodes/%s/%s/%d/firewall", targetNode, vmType, vmId), nil
+}
+
+// ipsets and aliases are identified by scope and name, as in
+// node1/qemu/100|webservers
+func firewallObjectId(scopeId string, name string) string {
+	return scopeId + "|" + name
+}
+
+func parseFirewallObjectId(resId string) (scopeId string, name string, err error) {
+	sep := strings.LastIndex(resId, "|")
+	if sep < 1 || sep == len(resId)-1 {
+		return "", "", fmt.Errorf("Invalid resource format: %s. Must be scope|name", resId)
+	}
+	return resId[:sep], resId[sep+1:], nil
+}
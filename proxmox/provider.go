@@ -3,6 +3,7 @@ package proxmox
 import (
 	"crypto/tls"
 	"fmt"
+	"net/http"
 	"regexp"
 	"strconv"
 	"sync"
@@ -18,6 +19,11 @@ type providerConfiguration struct {
 	MaxVMID         int
 	Mutex           *sync.Mutex
 	Cond            *sync.Cond
+	SshUser         string
+	SshPassword     string
+	SshPrivateKey   string
+	SshKnownHosts   string
+	TlsInsecure     bool
 }
 
 // Provider - Terrafrom properties for proxmox
@@ -27,17 +33,29 @@ func Provider() *schema.Provider {
 		Schema: map[string]*schema.Schema{
 			"pm_user": {
 				Type:        schema.TypeString,
-				Required:    true,
-				DefaultFunc: schema.EnvDefaultFunc("PM_USER", nil),
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("PM_USER", ""),
 				Description: "username, may begin with with @pam",
 			},
 			"pm_password": {
 				Type:        schema.TypeString,
-				Required:    true,
-				DefaultFunc: schema.EnvDefaultFunc("PM_PASS", nil),
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("PM_PASS", ""),
 				Description: "secret",
 				Sensitive:   true,
 			},
+			"pm_api_token_id": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("PM_API_TOKEN_ID", ""),
+				Description: "API token to use instead of pm_user, as in user@pam!terraform",
+			},
+			"pm_api_token_secret": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("PM_API_TOKEN_SECRET", ""),
+				Sensitive:   true,
+			},
 			"pm_api_url": {
 				Type:        schema.TypeString,
 				Required:    true,
@@ -54,14 +72,64 @@ func Provider() *schema.Provider {
 				Optional: true,
 				Default:  false,
 			},
+			"pm_ssh_user": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("PM_SSH_USER", "root"),
+				Description: "user to write files on the nodes with, as for snippets",
+			},
+			"pm_ssh_password": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("PM_SSH_PASS", ""),
+				Sensitive:   true,
+			},
+			"pm_ssh_private_key": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("PM_SSH_PRIVATE_KEY", ""),
+				Description: "private key in PEM format, tried before pm_ssh_password",
+				Sensitive:   true,
+			},
+			"pm_ssh_known_hosts": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("PM_SSH_KNOWN_HOSTS", ""),
+				Description: "known_hosts file with the host keys of the nodes, defaults to ~/.ssh/known_hosts",
+			},
 		},
 
 		ResourcesMap: map[string]*schema.Resource{
-			"proxmox_vm_qemu": resourceVmQemu(),
-			"proxmox_vm_lxc":  resourceVmLxc(),
-			// TODO - storage_iso
-			// TODO - bridge
-			// TODO - vm_qemu_template
+			"proxmox_vm_qemu":                 resourceVmQemu(),
+			"proxmox_vm_qemu_template":        resourceVmQemuTemplate(),
+			"proxmox_vm_lxc":                  resourceVmLxc(),
+			"proxmox_storage":                 resourceStorage(),
+			"proxmox_storage_iso":             resourceStorageIso(),
+			"proxmox_storage_snippet":         resourceStorageSnippet(),
+			"proxmox_network_bridge":          resourceNetworkBridge(),
+			"proxmox_network_vlan":            resourceNetworkVlan(),
+			"proxmox_network_bond":            resourceNetworkBond(),
+			"proxmox_pool":                    resourcePool(),
+			"proxmox_user":                    resourceUser(),
+			"proxmox_user_token":              resourceUserToken(),
+			"proxmox_group":                   resourceGroup(),
+			"proxmox_role":                    resourceRole(),
+			"proxmox_acl":                     resourceAcl(),
+			"proxmox_realm":                   resourceRealm(),
+			"proxmox_firewall_rules":          resourceFirewallRules(),
+			"proxmox_firewall_options":        resourceFirewallOptions(),
+			"proxmox_firewall_ipset":          resourceFirewallIpset(),
+			"proxmox_firewall_alias":          resourceFirewallAlias(),
+			"proxmox_firewall_security_group": resourceFirewallSecurityGroup(),
+			"proxmox_sdn_zone":                resourceSdnZone(),
+			"proxmox_sdn_vnet":                resourceSdnVnet(),
+			"proxmox_sdn_subnet":              resourceSdnSubnet(),
+			"proxmox_ha_group":                resourceHaGroup(),
+			"proxmox_ha_resource":             resourceHaResource(),
+			"proxmox_replication":             resourceReplication(),
+			"proxmox_backup_job":              resourceBackupJob(),
+			"proxmox_vm_backup":               resourceVmBackup(),
+			"proxmox_vm_snapshot":             resourceVmSnapshot(),
 		},
 
 		ConfigureFunc: providerConfigure,
@@ -69,7 +137,18 @@ func Provider() *schema.Provider {
 }
 
 func providerConfigure(d *schema.ResourceData) (interface{}, error) {
-	client, err := getClient(d.Get("pm_api_url").(string), d.Get("pm_user").(string), d.Get("pm_password").(string), d.Get("pm_tls_insecure").(bool))
+	var (
+		client *pxapi.Client
+		err    error
+	)
+
+	if tokenId := d.Get("pm_api_token_id").(string); tokenId != "" {
+		client, err = getTokenClient(d.Get("pm_api_url").(string), tokenId, d.Get("pm_api_token_secret").(string), d.Get("pm_tls_insecure").(bool))
+	} else if d.Get("pm_user").(string) != "" {
+		client, err = getClient(d.Get("pm_api_url").(string), d.Get("pm_user").(string), d.Get("pm_password").(string), d.Get("pm_tls_insecure").(bool))
+	} else {
+		err = fmt.Errorf("Either pm_user and pm_password or pm_api_token_id and pm_api_token_secret must be set")
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -81,6 +160,11 @@ func providerConfigure(d *schema.ResourceData) (interface{}, error) {
 		MaxVMID:         -1,
 		Mutex:           &mut,
 		Cond:            sync.NewCond(&mut),
+		SshUser:         d.Get("pm_ssh_user").(string),
+		SshPassword:     d.Get("pm_ssh_password").(string),
+		SshPrivateKey:   d.Get("pm_ssh_private_key").(string),
+		SshKnownHosts:   d.Get("pm_ssh_known_hosts").(string),
+		TlsInsecure:     d.Get("pm_tls_insecure").(bool),
 	}, nil
 }
 
@@ -97,6 +181,41 @@ func getClient(pm_api_url string, pm_user string, pm_password string, pm_tls_ins
 	return client, nil
 }
 
+// API tokens don't log in: proxmox takes the token in the Authorization header
+// of every request, in place of the ticket and CSRF token
+func getTokenClient(pm_api_url string, pm_api_token_id string, pm_api_token_secret string, pm_tls_insecure bool) (*pxapi.Client, error) {
+	tlsconf := &tls.Config{InsecureSkipVerify: true}
+	if !pm_tls_insecure {
+		tlsconf = nil
+	}
+	hclient := &http.Client{
+		Transport: &apiTokenTransport{
+			token: pm_api_token_id + "=" + pm_api_token_secret,
+			transport: &http.Transport{
+				TLSClientConfig: tlsconf,
+				Proxy:           http.ProxyFromEnvironment,
+			},
+		},
+	}
+	return pxapi.NewClient(pm_api_url, hclient, tlsconf)
+}
+
+type apiTokenTransport struct {
+	token     string
+	transport http.RoundTripper
+}
+
+// requests aren't modified by round trippers, so the header goes on a copy
+func (t *apiTokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	tokenReq := *req
+	tokenReq.Header = make(http.Header, len(req.Header)+1)
+	for k, v := range req.Header {
+		tokenReq.Header[k] = v
+	}
+	tokenReq.Header.Set("Authorization", "PVEAPIToken="+t.token)
+	return t.transport.RoundTrip(&tokenReq)
+}
+
 func nextVmId(pconf *providerConfiguration) (nextId int, err error) {
 	pconf.Mutex.Lock()
 	pconf.Client.Set()
@@ -141,3 +260,21 @@ func parseResourceId(resId string) (targetNode string, resType string, vmId int,
 	vmId, err = strconv.Atoi(idMatch[3])
 	return
 }
+
+// guests move between nodes, so attributes holding the resource id of a guest
+// only differ when the type or vmId do
+func resourceIdDiffSuppress(k, old, new string, d *schema.ResourceData) bool {
+	_, oldType, oldId, oldErr := parseResourceId(old)
+	_, newType, newId, newErr := parseResourceId(new)
+	return oldErr == nil && newErr == nil && oldType == newType && oldId == newId
+}
+
+// resource id of a guest on the node it currently is, or an empty id when
+// there's no such guest, for the resources that go away with the guest
+func findResourceId(vmId int) (string, error) {
+	guest, err := apiFindInList("/cluster/resources?type=vm", "vmid", float64(vmId))
+	if err != nil || guest == nil {
+		return "", err
+	}
+	return fmt.Sprintf("%v/%v/%d", guest["node"], guest["type"], vmId), nil
+}
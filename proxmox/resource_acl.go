@@ -0,0 +1,215 @@
+package proxmox
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+// api parameter holding the principal of each kind of acl entry
+var aclPrincipalParams = map[string]string{
+	"user":  "users",
+	"group": "groups",
+	"token": "tokens",
+}
+
+// One role given to one user, group or API token on a path
+func resourceAcl() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceAclCreate,
+		Read:   resourceAclRead,
+		Update: resourceAclUpdate,
+		Delete: resourceAclDelete,
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"path": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "Object the role applies to, such as /, /vms/100, /storage/local or /pool/web",
+			},
+			"role": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"user": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				ForceNew:      true,
+				ConflictsWith: []string{"group", "token"},
+			},
+			"group": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				ForceNew:      true,
+				ConflictsWith: []string{"user", "token"},
+			},
+			"token": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				ForceNew:      true,
+				ConflictsWith: []string{"user", "group"},
+				Description:   "API token, as in user@pve!token",
+			},
+			"propagate": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  true,
+			},
+		},
+	}
+}
+
+func resourceAclCreate(d *schema.ResourceData, meta interface{}) (err error) {
+	var (
+		aclType, ugid string
+
+		pconf = meta.(*providerConfiguration)
+		path  = d.Get("path").(string)
+		role  = d.Get("role").(string)
+	)
+
+	for aclType = range aclPrincipalParams {
+		if ugid = d.Get(aclType).(string); ugid != "" {
+			break
+		}
+	}
+
+	if ugid == "" {
+		return fmt.Errorf("One of user, group or token must be set")
+	}
+
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	err = aclPut(path, role, aclType, ugid, d.Get("propagate").(bool), false)
+
+	pmParallelEnd(pconf)
+
+	if err != nil {
+		return err
+	}
+
+	d.SetId(aclId(path, role, aclType, ugid))
+	return resourceAclRead(d, meta)
+}
+
+func resourceAclRead(d *schema.ResourceData, meta interface{}) (err error) {
+	var (
+		path, role, aclType, ugid string
+		acls                      []interface{}
+		acl                       map[string]interface{}
+	)
+
+	pconf := meta.(*providerConfiguration)
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	if path, role, aclType, ugid, err = parseAclId(d.Id()); err != nil {
+		d.SetId("")
+		goto End
+	}
+
+	if acls, err = apiGetList("/access/acl"); err != nil {
+		goto End
+	}
+
+	for _, v := range acls {
+		if v, isMap := v.(map[string]interface{}); isMap &&
+			v["path"] == path && v["roleid"] == role && v["type"] == aclType && v["ugid"] == ugid {
+			acl = v
+			break
+		}
+	}
+
+	// deleted outside of terraform
+	if acl == nil {
+		d.SetId("")
+		goto End
+	}
+
+	d.Set("path", path)
+	d.Set("role", role)
+	for k := range aclPrincipalParams {
+		d.Set(k, "")
+	}
+	d.Set(aclType, ugid)
+	d.Set("propagate", apiBool(acl["propagate"]))
+
+End:
+	pmParallelEnd(pconf)
+	return
+}
+
+func resourceAclUpdate(d *schema.ResourceData, meta interface{}) (err error) {
+	var path, role, aclType, ugid string
+
+	pconf := meta.(*providerConfiguration)
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	if path, role, aclType, ugid, err = parseAclId(d.Id()); err == nil {
+		err = aclPut(path, role, aclType, ugid, d.Get("propagate").(bool), false)
+	}
+
+	pmParallelEnd(pconf)
+
+	if err != nil {
+		return err
+	}
+
+	return resourceAclRead(d, meta)
+}
+
+func resourceAclDelete(d *schema.ResourceData, meta interface{}) (err error) {
+	var path, role, aclType, ugid string
+
+	pconf := meta.(*providerConfiguration)
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	if path, role, aclType, ugid, err = parseAclId(d.Id()); err == nil {
+		err = aclPut(path, role, aclType, ugid, false, true)
+	}
+
+	pmParallelEnd(pconf)
+	return
+}
+
+// add or, with remove set, delete an acl entry
+func aclPut(path string, role string, aclType string, ugid string, propagate bool, remove bool) error {
+	params := map[string]interface{}{
+		"path":                      path,
+		"roles":                     role,
+		aclPrincipalParams[aclType]: ugid,
+		"propagate":                 0,
+	}
+	if propagate {
+		params["propagate"] = 1
+	}
+	if remove {
+		params["delete"] = 1
+	}
+
+	_, err := apiPut("/access/acl", params)
+	return err
+}
+
+// acl entries are identified by path, role and principal, as in
+// /vms/100|PVEVMUser|group|operators
+func aclId(path string, role string, aclType string, ugid string) string {
+	return strings.Join([]string{path, role, aclType, ugid}, "|")
+}
+
+func parseAclId(resId string) (path string, role string, aclType string, ugid string, err error) {
+	idParts := strings.Split(resId, "|")
+	if len(idParts) != 4 || aclPrincipalParams[idParts[2]] == "" {
+		return "", "", "", "", fmt.Errorf("Invalid resource format: %s. Must be path|role|user, group or token|principal", resId)
+	}
+	return idParts[0], idParts[1], idParts[2], idParts[3], nil
+}
@@ -0,0 +1,262 @@
+package proxmox
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+)
+
+var backupJobFields = map[string]string{
+	"schedule":         "schedule",
+	"storage":          "storage",
+	"mode":             "mode",
+	"compress":         "compress",
+	"pool":             "pool",
+	"all":              "all",
+	"node":             "node",
+	"mailto":           "mailto",
+	"mailnotification": "mailnotification",
+	"comment":          "comment",
+}
+
+// Scheduled vzdump jobs of the cluster. The guests backed up are those in
+// vmids, those in pool, or all of them with all, minus the ones in exclude.
+func resourceBackupJob() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceBackupJobCreate,
+		Read:   resourceBackupJobRead,
+		Update: resourceBackupJobUpdate,
+		Delete: resourceBackupJobDelete,
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"job_id": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"schedule": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "Calendar event, as in \"sat 02:00\" or \"*-*-* 21:30\"",
+			},
+			"storage": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"mode": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "snapshot",
+				ValidateFunc: validation.StringInSlice([]string{"snapshot", "suspend", "stop"}, false),
+			},
+			"compress": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "zstd",
+				ValidateFunc: validation.StringInSlice([]string{"0", "gzip", "lzo", "zstd"}, false),
+			},
+			"vmids": {
+				Type:          schema.TypeSet,
+				Optional:      true,
+				Elem:          &schema.Schema{Type: schema.TypeInt},
+				ConflictsWith: []string{"pool", "all"},
+			},
+			"pool": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				ConflictsWith: []string{"vmids", "all"},
+			},
+			"all": {
+				Type:          schema.TypeBool,
+				Optional:      true,
+				Default:       false,
+				ConflictsWith: []string{"vmids", "pool"},
+			},
+			"exclude": {
+				Type:        schema.TypeSet,
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeInt},
+				Description: "Guests left out, with all or pool",
+			},
+			"node": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Only back up the guests on this node",
+			},
+			"prune_backups": storagePruneSchema(),
+			"mailto": {
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"mailnotification": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "always",
+				ValidateFunc: validation.StringInSlice([]string{"always", "failure"}, false),
+			},
+			"enabled": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  true,
+			},
+			"comment": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+		},
+	}
+}
+
+func resourceBackupJobCreate(d *schema.ResourceData, meta interface{}) (err error) {
+	var (
+		pconf  = meta.(*providerConfiguration)
+		id     = d.Get("job_id").(string)
+		params = backupJobParams(d, false)
+	)
+
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	params["id"] = id
+
+	_, err = apiPost("/cluster/backup", params)
+
+	pmParallelEnd(pconf)
+
+	if err != nil {
+		return err
+	}
+
+	d.SetId(id)
+	return resourceBackupJobRead(d, meta)
+}
+
+func resourceBackupJobRead(d *schema.ResourceData, meta interface{}) (err error) {
+	var job map[string]interface{}
+
+	pconf := meta.(*providerConfiguration)
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	if job, err = apiFindInList("/cluster/backup", "id", d.Id()); err != nil {
+		goto End
+	}
+
+	// deleted outside of terraform
+	if job == nil {
+		d.SetId("")
+		goto End
+	}
+
+	d.Set("job_id", d.Id())
+	d.Set("vmids", backupJobVmIds(job["vmid"]))
+	d.Set("exclude", backupJobVmIds(job["exclude"]))
+	d.Set("enabled", job["enabled"] == nil || apiBool(job["enabled"]))
+
+	if err = setApiFields(d, resourceBackupJob().Schema, backupJobFields, job); err != nil {
+		goto End
+	}
+
+	err = d.Set("prune_backups", storagePruneList(d, job["prune-backups"]))
+
+End:
+	pmParallelEnd(pconf)
+	return
+}
+
+func resourceBackupJobUpdate(d *schema.ResourceData, meta interface{}) (err error) {
+	pconf := meta.(*providerConfiguration)
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	_, err = apiPut("/cluster/backup/"+d.Id(), backupJobParams(d, true))
+
+	pmParallelEnd(pconf)
+
+	if err != nil {
+		return err
+	}
+
+	return resourceBackupJobRead(d, meta)
+}
+
+func resourceBackupJobDelete(d *schema.ResourceData, meta interface{}) (err error) {
+	pconf := meta.(*providerConfiguration)
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	_, err = apiDelete("/cluster/backup/"+d.Id(), nil)
+
+	pmParallelEnd(pconf)
+	return
+}
+
+// parameters for create and update, adding the guest lists, retention and
+// enabled flag to the job fields
+func backupJobParams(d *schema.ResourceData, update bool) map[string]interface{} {
+	var (
+		params  = apiParams(d, backupJobFields, ",", update)
+		deleted []string
+	)
+
+	if deletedFields, _ := params["delete"].(string); deletedFields != "" {
+		deleted = strings.Split(deletedFields, ",")
+	}
+
+	for attr, param := range map[string]string{"vmids": "vmid", "exclude": "exclude"} {
+		if update && !d.HasChange(attr) {
+			continue
+		}
+		if vmids := backupJobVmIdsParam(d.Get(attr).(*schema.Set)); vmids != "" {
+			params[param] = vmids
+		} else if update {
+			deleted = append(deleted, param)
+		}
+	}
+
+	if prune := storagePruneParam(d); prune != "" {
+		params["prune-backups"] = prune
+	} else if update && d.HasChange("prune_backups") {
+		deleted = append(deleted, "prune-backups")
+	}
+
+	params["enabled"] = 0
+	if d.Get("enabled").(bool) {
+		params["enabled"] = 1
+	}
+
+	delete(params, "delete")
+	if len(deleted) > 0 {
+		sort.Strings(deleted)
+		params["delete"] = strings.Join(deleted, ",")
+	}
+	return params
+}
+
+func backupJobVmIdsParam(vmids *schema.Set) string {
+	var vmidList []int
+	for _, vmid := range vmids.List() {
+		vmidList = append(vmidList, vmid.(int))
+	}
+	sort.Ints(vmidList)
+
+	var vmidStrings []string
+	for _, vmid := range vmidList {
+		vmidStrings = append(vmidStrings, strconv.Itoa(vmid))
+	}
+	return strings.Join(vmidStrings, ",")
+}
+
+func backupJobVmIds(vmidsValue interface{}) (vmids []interface{}) {
+	for _, vmid := range apiStringList(vmidsValue) {
+		vmids = append(vmids, apiInt(vmid))
+	}
+	return
+}
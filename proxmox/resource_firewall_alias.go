@@ -0,0 +1,154 @@
+package proxmox
+
+import (
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+)
+
+// Named addresses or networks of the cluster or of a guest, used in rules
+// and ipsets in place of the address
+func resourceFirewallAlias() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceFirewallAliasCreate,
+		Read:   resourceFirewallAliasRead,
+		Update: resourceFirewallAliasUpdate,
+		Delete: resourceFirewallAliasDelete,
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+
+		Schema: firewallScopeSchema(map[string]*schema.Schema{
+			"name": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.StringMatch(rxFirewallName, "must be lowercase letters, digits, - and _, starting with a letter"),
+			},
+			"cidr": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"comment": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+		}, false),
+	}
+}
+
+func resourceFirewallAliasCreate(d *schema.ResourceData, meta interface{}) (err error) {
+	var (
+		path string
+
+		pconf   = meta.(*providerConfiguration)
+		scopeId = firewallScopeId(d)
+		name    = d.Get("name").(string)
+	)
+
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	if path, err = firewallApiPath(scopeId); err == nil {
+		_, err = apiPost(path+"/aliases", map[string]interface{}{
+			"name":    name,
+			"cidr":    d.Get("cidr").(string),
+			"comment": d.Get("comment").(string),
+		})
+	}
+
+	pmParallelEnd(pconf)
+
+	if err != nil {
+		return err
+	}
+
+	d.SetId(firewallObjectId(scopeId, name))
+	return resourceFirewallAliasRead(d, meta)
+}
+
+func resourceFirewallAliasRead(d *schema.ResourceData, meta interface{}) (err error) {
+	var (
+		scopeId, name, path, cidr, comment string
+		alias                              map[string]interface{}
+	)
+
+	pconf := meta.(*providerConfiguration)
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	if scopeId, name, err = parseFirewallObjectId(d.Id()); err == nil {
+		path, err = firewallApiPath(scopeId)
+	}
+	if err != nil {
+		d.SetId("")
+		goto End
+	}
+
+	if alias, err = apiFindInList(path+"/aliases", "name", name); err != nil {
+		goto End
+	}
+
+	// deleted outside of terraform
+	if alias == nil {
+		d.SetId("")
+		goto End
+	}
+
+	cidr, _ = alias["cidr"].(string)
+	comment, _ = alias["comment"].(string)
+
+	setFirewallScope(d, scopeId)
+	d.Set("name", name)
+	d.Set("cidr", cidr)
+	d.Set("comment", strings.TrimSpace(comment))
+
+End:
+	pmParallelEnd(pconf)
+	return
+}
+
+func resourceFirewallAliasUpdate(d *schema.ResourceData, meta interface{}) (err error) {
+	var scopeId, name, path string
+
+	pconf := meta.(*providerConfiguration)
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	if scopeId, name, err = parseFirewallObjectId(d.Id()); err == nil {
+		path, err = firewallApiPath(scopeId)
+	}
+	if err == nil {
+		_, err = apiPut(path+"/aliases/"+name, map[string]interface{}{
+			"cidr":    d.Get("cidr").(string),
+			"comment": d.Get("comment").(string),
+		})
+	}
+
+	pmParallelEnd(pconf)
+
+	if err != nil {
+		return err
+	}
+
+	return resourceFirewallAliasRead(d, meta)
+}
+
+func resourceFirewallAliasDelete(d *schema.ResourceData, meta interface{}) (err error) {
+	var scopeId, name, path string
+
+	pconf := meta.(*providerConfiguration)
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	if scopeId, name, err = parseFirewallObjectId(d.Id()); err == nil {
+		path, err = firewallApiPath(scopeId)
+	}
+	if err == nil {
+		_, err = apiDelete(path+"/aliases/"+name, nil)
+	}
+
+	pmParallelEnd(pconf)
+	return
+}
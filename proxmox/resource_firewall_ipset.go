@@ -0,0 +1,282 @@
+package proxmox
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+)
+
+// IP sets of the cluster or of a guest, used in rules as +name
+func resourceFirewallIpset() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceFirewallIpsetCreate,
+		Read:   resourceFirewallIpsetRead,
+		Update: resourceFirewallIpsetUpdate,
+		Delete: resourceFirewallIpsetDelete,
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+
+		Schema: firewallScopeSchema(map[string]*schema.Schema{
+			"name": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.StringMatch(rxFirewallName, "must be lowercase letters, digits, - and _, starting with a letter"),
+			},
+			"comment": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"cidr": {
+				Type:     schema.TypeSet,
+				Optional: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"cidr": {
+							Type:        schema.TypeString,
+							Required:    true,
+							Description: "Address or network, or alias name",
+						},
+						"nomatch": {
+							Type:        schema.TypeBool,
+							Optional:    true,
+							Default:     false,
+							Description: "Exclude the address from the set",
+						},
+						"comment": {
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+					},
+				},
+			},
+		}, false),
+	}
+}
+
+func resourceFirewallIpsetCreate(d *schema.ResourceData, meta interface{}) (err error) {
+	var (
+		path string
+
+		pconf   = meta.(*providerConfiguration)
+		scopeId = firewallScopeId(d)
+		name    = d.Get("name").(string)
+	)
+
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	if path, err = firewallApiPath(scopeId); err != nil {
+		goto End
+	}
+
+	if _, err = apiPost(path+"/ipset", map[string]interface{}{
+		"name":    name,
+		"comment": d.Get("comment").(string),
+	}); err != nil {
+		goto End
+	}
+
+	d.SetId(firewallObjectId(scopeId, name))
+
+	err = firewallIpsetApply(path+"/ipset/"+name, d.Get("cidr").(*schema.Set).List())
+
+End:
+	pmParallelEnd(pconf)
+
+	if err != nil {
+		return err
+	}
+
+	return resourceFirewallIpsetRead(d, meta)
+}
+
+func resourceFirewallIpsetRead(d *schema.ResourceData, meta interface{}) (err error) {
+	var (
+		scopeId, name, path, comment string
+		ipset                        map[string]interface{}
+		entries                      []interface{}
+		cidrs                        []interface{}
+	)
+
+	pconf := meta.(*providerConfiguration)
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	if scopeId, name, err = parseFirewallObjectId(d.Id()); err == nil {
+		path, err = firewallApiPath(scopeId)
+	}
+	if err != nil {
+		d.SetId("")
+		goto End
+	}
+
+	if ipset, err = apiFindInList(path+"/ipset", "name", name); err != nil {
+		goto End
+	}
+
+	// deleted outside of terraform
+	if ipset == nil {
+		d.SetId("")
+		goto End
+	}
+
+	if entries, err = apiGetList(path + "/ipset/" + name); err != nil {
+		goto End
+	}
+
+	for _, v := range entries {
+		if entry, isMap := v.(map[string]interface{}); isMap {
+			cidrs = append(cidrs, firewallApiIpsetEntry(entry))
+		}
+	}
+
+	comment, _ = ipset["comment"].(string)
+
+	setFirewallScope(d, scopeId)
+	d.Set("name", name)
+	d.Set("comment", strings.TrimSpace(comment))
+	err = d.Set("cidr", cidrs)
+
+End:
+	pmParallelEnd(pconf)
+	return
+}
+
+func resourceFirewallIpsetUpdate(d *schema.ResourceData, meta interface{}) (err error) {
+	var scopeId, name, path string
+
+	pconf := meta.(*providerConfiguration)
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	if scopeId, name, err = parseFirewallObjectId(d.Id()); err != nil {
+		goto End
+	}
+	if path, err = firewallApiPath(scopeId); err != nil {
+		goto End
+	}
+
+	// the comment is changed by "renaming" the set to its own name
+	if d.HasChange("comment") {
+		if _, err = apiPost(path+"/ipset", map[string]interface{}{
+			"name":    name,
+			"rename":  name,
+			"comment": d.Get("comment").(string),
+		}); err != nil {
+			goto End
+		}
+	}
+
+	err = firewallIpsetApply(path+"/ipset/"+name, d.Get("cidr").(*schema.Set).List())
+
+End:
+	pmParallelEnd(pconf)
+
+	if err != nil {
+		return err
+	}
+
+	return resourceFirewallIpsetRead(d, meta)
+}
+
+func resourceFirewallIpsetDelete(d *schema.ResourceData, meta interface{}) (err error) {
+	var scopeId, name, path string
+
+	pconf := meta.(*providerConfiguration)
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	if scopeId, name, err = parseFirewallObjectId(d.Id()); err != nil {
+		goto End
+	}
+	if path, err = firewallApiPath(scopeId); err != nil {
+		goto End
+	}
+
+	// only empty sets can be deleted
+	if err = firewallIpsetApply(path+"/ipset/"+name, nil); err != nil {
+		goto End
+	}
+
+	_, err = apiDelete(path+"/ipset/"+name, nil)
+
+End:
+	pmParallelEnd(pconf)
+	return
+}
+
+// make the entries of the ipset at path be the given ones
+func firewallIpsetApply(path string, cidrs []interface{}) (err error) {
+	var (
+		entries []interface{}
+		current = map[string]map[string]interface{}{}
+		wanted  = map[string]bool{}
+	)
+
+	if entries, err = apiGetList(path); err != nil {
+		return
+	}
+
+	for _, v := range entries {
+		if entry, isMap := v.(map[string]interface{}); isMap {
+			entry = firewallApiIpsetEntry(entry)
+			current[entry["cidr"].(string)] = entry
+		}
+	}
+
+	for _, v := range cidrs {
+		wanted[v.(map[string]interface{})["cidr"].(string)] = true
+	}
+
+	for cidr := range current {
+		if !wanted[cidr] {
+			log.Printf("[DEBUG] deleting %s from ipset %s", cidr, path)
+			if _, err = apiDelete(path+"/"+url.PathEscape(cidr), nil); err != nil {
+				return
+			}
+		}
+	}
+
+	for _, v := range cidrs {
+		entry := v.(map[string]interface{})
+		cidr := entry["cidr"].(string)
+
+		params := map[string]interface{}{
+			"comment": entry["comment"].(string),
+			"nomatch": 0,
+		}
+		if entry["nomatch"].(bool) {
+			params["nomatch"] = 1
+		}
+
+		if currentEntry, exists := current[cidr]; !exists {
+			params["cidr"] = cidr
+			_, err = apiPost(path, params)
+		} else if currentEntry["comment"] != entry["comment"] || currentEntry["nomatch"] != entry["nomatch"] {
+			_, err = apiPut(path+"/"+url.PathEscape(cidr), params)
+		}
+
+		if err != nil {
+			return fmt.Errorf("Error setting %s in ipset %s: %v", cidr, path, err)
+		}
+	}
+	return
+}
+
+// convert an ipset entry of the api to a cidr block
+func firewallApiIpsetEntry(entry map[string]interface{}) map[string]interface{} {
+	cidr, _ := entry["cidr"].(string)
+	comment, _ := entry["comment"].(string)
+
+	return map[string]interface{}{
+		"cidr":    cidr,
+		"nomatch": apiBool(entry["nomatch"]),
+		"comment": strings.TrimSpace(comment),
+	}
+}
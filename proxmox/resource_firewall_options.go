@@ -0,0 +1,232 @@
+package proxmox
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+)
+
+var firewallOptionFields = map[string]string{
+	"policy_in":     "policy_in",
+	"policy_out":    "policy_out",
+	"log_level_in":  "log_level_in",
+	"log_level_out": "log_level_out",
+}
+
+// flags are always sent when set, false included, as some of them default to
+// true in proxmox
+var firewallOptionFlags = map[string]string{
+	"enable":    "enable",
+	"dhcp":      "dhcp",
+	"ndp":       "ndp",
+	"radv":      "radv",
+	"ipfilter":  "ipfilter",
+	"macfilter": "macfilter",
+}
+
+// Firewall options of a scope. Not every option applies to every scope:
+// policy_in and policy_out are for the cluster and guests, dhcp, radv,
+// ipfilter and macfilter for guests only. The options left unset keep the
+// proxmox defaults, which they go back to when the resource is destroyed.
+func resourceFirewallOptions() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceFirewallOptionsCreate,
+		Read:   resourceFirewallOptionsRead,
+		Update: resourceFirewallOptionsUpdate,
+		Delete: resourceFirewallOptionsDelete,
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+
+		Schema: firewallScopeSchema(map[string]*schema.Schema{
+			"enable": {
+				Type:     schema.TypeBool,
+				Optional: true,
+			},
+			"policy_in": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringInSlice(firewallActions, false),
+			},
+			"policy_out": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringInSlice(firewallActions, false),
+			},
+			"log_level_in": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringInSlice(firewallLogLevels, false),
+			},
+			"log_level_out": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringInSlice(firewallLogLevels, false),
+			},
+			"dhcp": {
+				Type:     schema.TypeBool,
+				Optional: true,
+			},
+			"ndp": {
+				Type:     schema.TypeBool,
+				Optional: true,
+			},
+			"radv": {
+				Type:     schema.TypeBool,
+				Optional: true,
+			},
+			"ipfilter": {
+				Type:     schema.TypeBool,
+				Optional: true,
+			},
+			"macfilter": {
+				Type:     schema.TypeBool,
+				Optional: true,
+			},
+		}, true),
+	}
+}
+
+func resourceFirewallOptionsCreate(d *schema.ResourceData, meta interface{}) (err error) {
+	var (
+		path string
+
+		pconf   = meta.(*providerConfiguration)
+		scopeId = firewallScopeId(d)
+	)
+
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	if path, err = firewallApiPath(scopeId); err != nil {
+		goto End
+	}
+
+	if _, err = apiPut(path+"/options", firewallOptionsParams(d, false)); err != nil {
+		goto End
+	}
+
+	d.SetId(scopeId)
+
+End:
+	pmParallelEnd(pconf)
+
+	if err != nil {
+		return err
+	}
+
+	return resourceFirewallOptionsRead(d, meta)
+}
+
+func resourceFirewallOptionsRead(d *schema.ResourceData, meta interface{}) (err error) {
+	var (
+		path    string
+		options map[string]interface{}
+	)
+
+	pconf := meta.(*providerConfiguration)
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	if path, err = firewallApiPath(d.Id()); err != nil {
+		d.SetId("")
+		goto End
+	}
+
+	if options, err = apiGetMap(path + "/options"); err != nil {
+		goto End
+	}
+
+	setFirewallScope(d, d.Id())
+
+	if err = setApiFields(d, resourceFirewallOptions().Schema, firewallOptionFields, options); err != nil {
+		goto End
+	}
+	err = setApiFields(d, resourceFirewallOptions().Schema, firewallOptionFlags, options)
+
+End:
+	pmParallelEnd(pconf)
+	return
+}
+
+func resourceFirewallOptionsUpdate(d *schema.ResourceData, meta interface{}) (err error) {
+	var path string
+
+	pconf := meta.(*providerConfiguration)
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	if path, err = firewallApiPath(d.Id()); err == nil {
+		_, err = apiPut(path+"/options", firewallOptionsParams(d, true))
+	}
+
+	pmParallelEnd(pconf)
+
+	if err != nil {
+		return err
+	}
+
+	return resourceFirewallOptionsRead(d, meta)
+}
+
+func resourceFirewallOptionsDelete(d *schema.ResourceData, meta interface{}) (err error) {
+	var (
+		path    string
+		deleted []string
+	)
+
+	pconf := meta.(*providerConfiguration)
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	for _, fields := range []map[string]string{firewallOptionFields, firewallOptionFlags} {
+		for _, param := range fields {
+			deleted = append(deleted, param)
+		}
+	}
+	sort.Strings(deleted)
+
+	if path, err = firewallApiPath(d.Id()); err == nil {
+		_, err = apiPut(path+"/options", map[string]interface{}{
+			"delete": strings.Join(deleted, ","),
+		})
+	}
+
+	pmParallelEnd(pconf)
+	return
+}
+
+func firewallOptionsParams(d *schema.ResourceData, update bool) map[string]interface{} {
+	var (
+		params  = apiParams(d, firewallOptionFields, ",", update)
+		deleted []string
+	)
+
+	if deletedFields, _ := params["delete"].(string); deletedFields != "" {
+		deleted = strings.Split(deletedFields, ",")
+	}
+
+	for attr, param := range firewallOptionFlags {
+		if update && !d.HasChange(attr) {
+			continue
+		}
+		if flag, isSet := d.GetOkExists(attr); !isSet {
+			if update {
+				deleted = append(deleted, param)
+			}
+		} else if flag.(bool) {
+			params[param] = 1
+		} else {
+			params[param] = 0
+		}
+	}
+
+	delete(params, "delete")
+	if len(deleted) > 0 {
+		sort.Strings(deleted)
+		params["delete"] = strings.Join(deleted, ",")
+	}
+	return params
+}
@@ -0,0 +1,324 @@
+package proxmox
+
+import (
+	"fmt"
+	"log"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+)
+
+// optional string attributes of a rule, named as the api parameters
+var firewallRuleFields = []string{"comment", "dest", "dport", "iface", "log", "macro", "proto", "source", "sport"}
+
+// The whole list of rules of a scope, in order. The rules of a scope are
+// managed by a single resource, rule i being kept at the position of the
+// i-th rule of the scope. Security group entries aren't rules of this
+// resource, they're set with firewall_security_groups on the guests.
+func resourceFirewallRules() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceFirewallRulesCreate,
+		Read:   resourceFirewallRulesRead,
+		Update: resourceFirewallRulesUpdate,
+		Delete: resourceFirewallRulesDelete,
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+
+		Schema: firewallScopeSchema(map[string]*schema.Schema{
+			"rule": {
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem:     &schema.Resource{Schema: firewallRuleSchema()},
+			},
+		}, true),
+	}
+}
+
+func firewallRuleSchema() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"type": {
+			Type:         schema.TypeString,
+			Required:     true,
+			ValidateFunc: validation.StringInSlice([]string{"in", "out"}, false),
+		},
+		"action": {
+			Type:         schema.TypeString,
+			Required:     true,
+			ValidateFunc: validation.StringInSlice(firewallActions, false),
+		},
+		"enable": {
+			Type:     schema.TypeBool,
+			Optional: true,
+			Default:  true,
+		},
+		"macro": {
+			Type:        schema.TypeString,
+			Optional:    true,
+			Description: "Predefined set of protocols and ports, such as SSH or HTTPS",
+		},
+		"proto": {
+			Type:     schema.TypeString,
+			Optional: true,
+		},
+		"sport": {
+			Type:     schema.TypeString,
+			Optional: true,
+		},
+		"dport": {
+			Type:     schema.TypeString,
+			Optional: true,
+		},
+		"source": {
+			Type:        schema.TypeString,
+			Optional:    true,
+			Description: "Address, CIDR, range, alias or +ipset",
+		},
+		"dest": {
+			Type:        schema.TypeString,
+			Optional:    true,
+			Description: "Address, CIDR, range, alias or +ipset",
+		},
+		"iface": {
+			Type:     schema.TypeString,
+			Optional: true,
+		},
+		"log": {
+			Type:         schema.TypeString,
+			Optional:     true,
+			ValidateFunc: validation.StringInSlice(firewallLogLevels, false),
+		},
+		"comment": {
+			Type:     schema.TypeString,
+			Optional: true,
+		},
+	}
+}
+
+func resourceFirewallRulesCreate(d *schema.ResourceData, meta interface{}) (err error) {
+	var (
+		path string
+
+		pconf   = meta.(*providerConfiguration)
+		scopeId = firewallScopeId(d)
+	)
+
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	if path, err = firewallApiPath(scopeId); err != nil {
+		goto End
+	}
+
+	if err = firewallRulesApply(path+"/rules", d.Get("rule").([]interface{}), false); err != nil {
+		goto End
+	}
+
+	d.SetId(scopeId)
+
+End:
+	pmParallelEnd(pconf)
+
+	if err != nil {
+		return err
+	}
+
+	return resourceFirewallRulesRead(d, meta)
+}
+
+func resourceFirewallRulesRead(d *schema.ResourceData, meta interface{}) (err error) {
+	var (
+		path    string
+		entries []map[string]interface{}
+		rules   []interface{}
+	)
+
+	pconf := meta.(*providerConfiguration)
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	if path, err = firewallApiPath(d.Id()); err != nil {
+		d.SetId("")
+		goto End
+	}
+
+	if entries, _, err = firewallRuleEntries(path+"/rules", false); err != nil {
+		goto End
+	}
+
+	for _, entry := range entries {
+		rules = append(rules, firewallApiRule(entry))
+	}
+
+	setFirewallScope(d, d.Id())
+	err = d.Set("rule", rules)
+
+End:
+	pmParallelEnd(pconf)
+	return
+}
+
+func resourceFirewallRulesUpdate(d *schema.ResourceData, meta interface{}) (err error) {
+	var path string
+
+	pconf := meta.(*providerConfiguration)
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	if path, err = firewallApiPath(d.Id()); err == nil {
+		err = firewallRulesApply(path+"/rules", d.Get("rule").([]interface{}), false)
+	}
+
+	pmParallelEnd(pconf)
+
+	if err != nil {
+		return err
+	}
+
+	return resourceFirewallRulesRead(d, meta)
+}
+
+func resourceFirewallRulesDelete(d *schema.ResourceData, meta interface{}) (err error) {
+	var path string
+
+	pconf := meta.(*providerConfiguration)
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	if path, err = firewallApiPath(d.Id()); err == nil {
+		err = firewallRulesApply(path+"/rules", nil, false)
+	}
+
+	pmParallelEnd(pconf)
+	return
+}
+
+// Make the rules at rulesPath be the given ones, in order. The rules already
+// in place are changed where they differ, the ones left over deleted from the
+// last one so the positions before don't shift, and the missing ones added
+// after the last rule kept. With groups set the entries handled are the
+// security groups instead, the missing ones going to the top when there's
+// none yet.
+func firewallRulesApply(rulesPath string, rules []interface{}, groups bool) (err error) {
+	var (
+		entries []map[string]interface{}
+		total   int
+	)
+
+	if entries, total, err = firewallRuleEntries(rulesPath, groups); err != nil {
+		return
+	}
+
+	for i := 0; i < len(rules) && i < len(entries); i++ {
+		params := firewallRuleParams(rules[i].(map[string]interface{}))
+		current := firewallRuleParams(firewallApiRule(entries[i]))
+		if reflect.DeepEqual(params, current) {
+			continue
+		}
+
+		// only the fields the entry has are cleared
+		var deleted []string
+		for _, field := range firewallRuleFields {
+			_, isSet := params[field]
+			if _, wasSet := current[field]; wasSet && !isSet {
+				deleted = append(deleted, field)
+			}
+		}
+		if len(deleted) > 0 {
+			params["delete"] = strings.Join(deleted, ",")
+		}
+
+		log.Printf("[DEBUG] updating firewall rule %d of %s", i, rulesPath)
+		if _, err = apiPut(fmt.Sprintf("%s/%d", rulesPath, apiInt(entries[i]["pos"])), params); err != nil {
+			return
+		}
+	}
+
+	for i := len(entries) - 1; i >= len(rules); i-- {
+		log.Printf("[DEBUG] deleting firewall rule %d of %s", i, rulesPath)
+		if _, err = apiDelete(fmt.Sprintf("%s/%d", rulesPath, apiInt(entries[i]["pos"])), nil); err != nil {
+			return
+		}
+		total--
+	}
+
+	pos := total
+	if groups {
+		pos = 0
+	}
+	if len(entries) > 0 && len(rules) > len(entries) {
+		pos = apiInt(entries[len(entries)-1]["pos"]) + 1
+	}
+
+	for i := len(entries); i < len(rules); i++ {
+		params := firewallRuleParams(rules[i].(map[string]interface{}))
+		params["pos"] = pos
+
+		log.Printf("[DEBUG] adding firewall rule %d to %s", i, rulesPath)
+		if _, err = apiPost(rulesPath, params); err != nil {
+			return
+		}
+		pos++
+	}
+	return
+}
+
+// return the in and out rules at rulesPath ordered by position, or with
+// groups set the security group entries, along with the count of all the
+// entries
+func firewallRuleEntries(rulesPath string, groups bool) (rules []map[string]interface{}, total int, err error) {
+	list, err := apiGetList(rulesPath)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	for _, v := range list {
+		entry, isMap := v.(map[string]interface{})
+		if !isMap {
+			continue
+		}
+		total++
+		if (entry["type"] == "group") == groups {
+			rules = append(rules, entry)
+		}
+	}
+
+	sort.Slice(rules, func(i, j int) bool {
+		return apiInt(rules[i]["pos"]) < apiInt(rules[j]["pos"])
+	})
+	return
+}
+
+func firewallRuleParams(rule map[string]interface{}) map[string]interface{} {
+	params := map[string]interface{}{
+		"type":   rule["type"],
+		"action": rule["action"],
+		"enable": 0,
+	}
+	if rule["enable"].(bool) {
+		params["enable"] = 1
+	}
+	for _, field := range firewallRuleFields {
+		if value, _ := rule[field].(string); value != "" {
+			params[field] = value
+		}
+	}
+	return params
+}
+
+// convert a rule entry of the api to a rule block
+func firewallApiRule(entry map[string]interface{}) map[string]interface{} {
+	rule := map[string]interface{}{
+		"type":   entry["type"],
+		"action": entry["action"],
+		"enable": apiBool(entry["enable"]),
+	}
+	for _, field := range firewallRuleFields {
+		value, _ := entry[field].(string)
+		rule[field] = strings.TrimSpace(value)
+	}
+	return rule
+}
@@ -0,0 +1,186 @@
+package proxmox
+
+import (
+	"strings"
+
+	pxapi "github.com/3coma3/proxmox-api-go/proxmox"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+)
+
+// Cluster security groups, lists of rules that guests include through
+// firewall_security_groups. The rules are reconciled in order like those of
+// proxmox_firewall_rules.
+func resourceFirewallSecurityGroup() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceFirewallSecurityGroupCreate,
+		Read:   resourceFirewallSecurityGroupRead,
+		Update: resourceFirewallSecurityGroupUpdate,
+		Delete: resourceFirewallSecurityGroupDelete,
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.StringMatch(rxFirewallName, "must be lowercase letters, digits, - and _, starting with a letter"),
+			},
+			"comment": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"rule": {
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem:     &schema.Resource{Schema: firewallRuleSchema()},
+			},
+		},
+	}
+}
+
+func resourceFirewallSecurityGroupCreate(d *schema.ResourceData, meta interface{}) (err error) {
+	var (
+		pconf = meta.(*providerConfiguration)
+		name  = d.Get("name").(string)
+	)
+
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	if _, err = apiPost("/cluster/firewall/groups", map[string]interface{}{
+		"group":   name,
+		"comment": d.Get("comment").(string),
+	}); err != nil {
+		goto End
+	}
+
+	d.SetId(name)
+
+	err = firewallRulesApply("/cluster/firewall/groups/"+name, d.Get("rule").([]interface{}), false)
+
+End:
+	pmParallelEnd(pconf)
+
+	if err != nil {
+		return err
+	}
+
+	return resourceFirewallSecurityGroupRead(d, meta)
+}
+
+func resourceFirewallSecurityGroupRead(d *schema.ResourceData, meta interface{}) (err error) {
+	var (
+		group   map[string]interface{}
+		entries []map[string]interface{}
+		rules   []interface{}
+		comment string
+	)
+
+	pconf := meta.(*providerConfiguration)
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	if group, err = apiFindInList("/cluster/firewall/groups", "group", d.Id()); err != nil {
+		goto End
+	}
+
+	// deleted outside of terraform
+	if group == nil {
+		d.SetId("")
+		goto End
+	}
+
+	if entries, _, err = firewallRuleEntries("/cluster/firewall/groups/"+d.Id(), false); err != nil {
+		goto End
+	}
+
+	for _, entry := range entries {
+		rules = append(rules, firewallApiRule(entry))
+	}
+
+	comment, _ = group["comment"].(string)
+
+	d.Set("name", d.Id())
+	d.Set("comment", strings.TrimSpace(comment))
+	err = d.Set("rule", rules)
+
+End:
+	pmParallelEnd(pconf)
+	return
+}
+
+func resourceFirewallSecurityGroupUpdate(d *schema.ResourceData, meta interface{}) (err error) {
+	pconf := meta.(*providerConfiguration)
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	// the comment is changed by "renaming" the group to its own name
+	if d.HasChange("comment") {
+		if _, err = apiPost("/cluster/firewall/groups", map[string]interface{}{
+			"group":   d.Id(),
+			"rename":  d.Id(),
+			"comment": d.Get("comment").(string),
+		}); err != nil {
+			goto End
+		}
+	}
+
+	err = firewallRulesApply("/cluster/firewall/groups/"+d.Id(), d.Get("rule").([]interface{}), false)
+
+End:
+	pmParallelEnd(pconf)
+
+	if err != nil {
+		return err
+	}
+
+	return resourceFirewallSecurityGroupRead(d, meta)
+}
+
+func resourceFirewallSecurityGroupDelete(d *schema.ResourceData, meta interface{}) (err error) {
+	pconf := meta.(*providerConfiguration)
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	// only empty groups can be deleted
+	if err = firewallRulesApply("/cluster/firewall/groups/"+d.Id(), nil, false); err == nil {
+		_, err = apiDelete("/cluster/firewall/groups/"+d.Id(), nil)
+	}
+
+	pmParallelEnd(pconf)
+	return
+}
+
+// the security groups included in the firewall of a guest, in order
+func vmFirewallGroups(vm *pxapi.Vm) (groups []string, err error) {
+	entries, _, err := firewallRuleEntries(vmApiPath(vm)+"/firewall/rules", true)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		if group, isString := entry["action"].(string); isString {
+			groups = append(groups, group)
+		}
+	}
+	return
+}
+
+// make the guest include the given security groups, in order, leaving its
+// other rules alone
+func vmFirewallGroupsApply(vm *pxapi.Vm, groups []interface{}) error {
+	var rules []interface{}
+
+	for _, group := range groups {
+		rules = append(rules, map[string]interface{}{
+			"type":   "group",
+			"action": group,
+			"enable": true,
+		})
+	}
+
+	return firewallRulesApply(vmApiPath(vm)+"/firewall/rules", rules, true)
+}
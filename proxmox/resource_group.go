@@ -0,0 +1,126 @@
+package proxmox
+
+import (
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+// Groups only hold the comment, members are added through the groups of
+// proxmox_user
+func resourceGroup() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceGroupCreate,
+		Read:   resourceGroupRead,
+		Update: resourceGroupUpdate,
+		Delete: resourceGroupDelete,
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"groupid": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"comment": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"members": {
+				Type:     schema.TypeSet,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+		},
+	}
+}
+
+func resourceGroupCreate(d *schema.ResourceData, meta interface{}) (err error) {
+	var (
+		pconf   = meta.(*providerConfiguration)
+		groupid = d.Get("groupid").(string)
+	)
+
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	_, err = apiPost("/access/groups", map[string]interface{}{
+		"groupid": groupid,
+		"comment": d.Get("comment").(string),
+	})
+
+	pmParallelEnd(pconf)
+
+	if err != nil {
+		return err
+	}
+
+	d.SetId(groupid)
+	return resourceGroupRead(d, meta)
+}
+
+func resourceGroupRead(d *schema.ResourceData, meta interface{}) (err error) {
+	var (
+		group   map[string]interface{}
+		comment string
+	)
+
+	pconf := meta.(*providerConfiguration)
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	if group, err = apiFindInList("/access/groups", "groupid", d.Id()); err != nil {
+		goto End
+	}
+
+	// deleted outside of terraform
+	if group == nil {
+		d.SetId("")
+		goto End
+	}
+
+	if group, err = apiGetMap("/access/groups/" + d.Id()); err != nil {
+		goto End
+	}
+
+	comment, _ = group["comment"].(string)
+
+	d.Set("groupid", d.Id())
+	d.Set("comment", strings.TrimSpace(comment))
+	d.Set("members", apiStringList(group["members"]))
+
+End:
+	pmParallelEnd(pconf)
+	return
+}
+
+func resourceGroupUpdate(d *schema.ResourceData, meta interface{}) (err error) {
+	pconf := meta.(*providerConfiguration)
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	_, err = apiPut("/access/groups/"+d.Id(), map[string]interface{}{
+		"comment": d.Get("comment").(string),
+	})
+
+	pmParallelEnd(pconf)
+
+	if err != nil {
+		return err
+	}
+
+	return resourceGroupRead(d, meta)
+}
+
+func resourceGroupDelete(d *schema.ResourceData, meta interface{}) (err error) {
+	pconf := meta.(*providerConfiguration)
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	_, err = apiDelete("/access/groups/"+d.Id(), nil)
+
+	pmParallelEnd(pconf)
+	return
+}
@@ -0,0 +1,175 @@
+package proxmox
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+var haGroupFields = map[string]string{
+	"comment":    "comment",
+	"restricted": "restricted",
+	"nofailback": "nofailback",
+}
+
+// HA groups, the nodes HA resources run on with their priorities
+func resourceHaGroup() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceHaGroupCreate,
+		Read:   resourceHaGroupRead,
+		Update: resourceHaGroupUpdate,
+		Delete: resourceHaGroupDelete,
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"group": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"nodes": {
+				Type:        schema.TypeMap,
+				Required:    true,
+				Elem:        &schema.Schema{Type: schema.TypeInt},
+				Description: "Node names with their priority, higher first, 0 being no priority",
+			},
+			"restricted": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Only run the resources on the nodes of the group",
+			},
+			"nofailback": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Don't move the resources back when a node with higher priority comes online",
+			},
+			"comment": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+		},
+	}
+}
+
+func resourceHaGroupCreate(d *schema.ResourceData, meta interface{}) (err error) {
+	var (
+		pconf  = meta.(*providerConfiguration)
+		group  = d.Get("group").(string)
+		params = apiParams(d, haGroupFields, ",", false)
+	)
+
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	params["group"] = group
+	params["type"] = "group"
+	params["nodes"] = haGroupNodesParam(d.Get("nodes").(map[string]interface{}))
+
+	_, err = apiPost("/cluster/ha/groups", params)
+
+	pmParallelEnd(pconf)
+
+	if err != nil {
+		return err
+	}
+
+	d.SetId(group)
+	return resourceHaGroupRead(d, meta)
+}
+
+func resourceHaGroupRead(d *schema.ResourceData, meta interface{}) (err error) {
+	var group map[string]interface{}
+
+	pconf := meta.(*providerConfiguration)
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	if group, err = apiFindInList("/cluster/ha/groups", "group", d.Id()); err != nil {
+		goto End
+	}
+
+	// deleted outside of terraform
+	if group == nil {
+		d.SetId("")
+		goto End
+	}
+
+	d.Set("group", d.Id())
+
+	if err = d.Set("nodes", haGroupNodes(group["nodes"])); err != nil {
+		goto End
+	}
+
+	err = setApiFields(d, resourceHaGroup().Schema, haGroupFields, group)
+
+End:
+	pmParallelEnd(pconf)
+	return
+}
+
+func resourceHaGroupUpdate(d *schema.ResourceData, meta interface{}) (err error) {
+	var params = apiParams(d, haGroupFields, ",", true)
+
+	pconf := meta.(*providerConfiguration)
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	params["nodes"] = haGroupNodesParam(d.Get("nodes").(map[string]interface{}))
+
+	_, err = apiPut("/cluster/ha/groups/"+d.Id(), params)
+
+	pmParallelEnd(pconf)
+
+	if err != nil {
+		return err
+	}
+
+	return resourceHaGroupRead(d, meta)
+}
+
+func resourceHaGroupDelete(d *schema.ResourceData, meta interface{}) (err error) {
+	pconf := meta.(*providerConfiguration)
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	_, err = apiDelete("/cluster/ha/groups/"+d.Id(), nil)
+
+	pmParallelEnd(pconf)
+	return
+}
+
+// build the nodes parameter, as in node1:2,node2:1,node3
+func haGroupNodesParam(nodes map[string]interface{}) string {
+	var nodeList []string
+
+	for node, priority := range nodes {
+		if priority.(int) > 0 {
+			node = fmt.Sprintf("%s:%d", node, priority.(int))
+		}
+		nodeList = append(nodeList, node)
+	}
+	sort.Strings(nodeList)
+
+	return strings.Join(nodeList, ",")
+}
+
+func haGroupNodes(nodesValue interface{}) map[string]interface{} {
+	nodes := map[string]interface{}{}
+
+	for _, node := range apiStringList(nodesValue) {
+		nodeParts := strings.SplitN(node, ":", 2)
+		priority := 0
+		if len(nodeParts) == 2 {
+			priority, _ = strconv.Atoi(nodeParts[1])
+		}
+		nodes[nodeParts[0]] = priority
+	}
+	return nodes
+}
@@ -0,0 +1,244 @@
+package proxmox
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	pxapi "github.com/3coma3/proxmox-api-go/proxmox"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+)
+
+// A guest managed by HA. It's identified by its HA id, as in vm:100 or ct:101.
+func resourceHaResource() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceHaResourceCreate,
+		Read:   resourceHaResourceRead,
+		Update: resourceHaResourceUpdate,
+		Delete: resourceHaResourceDelete,
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"vm": {
+				Type:             schema.TypeString,
+				Required:         true,
+				ForceNew:         true,
+				Description:      "Id of a proxmox_vm_qemu or proxmox_vm_lxc, as in node/qemu/100",
+				DiffSuppressFunc: resourceIdDiffSuppress,
+			},
+			"state": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "started",
+				ValidateFunc: validation.StringInSlice([]string{"started", "stopped", "enabled", "disabled", "ignored"}, false),
+			},
+			"group": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"max_restart": {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				Default:     1,
+				Description: "Restarts tried on the same node after a failed start",
+			},
+			"max_relocate": {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				Default:     1,
+				Description: "Relocations tried to other nodes after a failed start",
+			},
+			"comment": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+		},
+	}
+}
+
+func resourceHaResourceCreate(d *schema.ResourceData, meta interface{}) (err error) {
+	var (
+		pconf  = meta.(*providerConfiguration)
+		sid    = haResourceSid(d.Get("vm").(string))
+		params = haResourceParams(d, false)
+	)
+
+	if sid == "" {
+		return fmt.Errorf("Invalid vm: %s. Must be node/type/vmId", d.Get("vm").(string))
+	}
+
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	params["sid"] = sid
+
+	_, err = apiPost("/cluster/ha/resources", params)
+
+	pmParallelEnd(pconf)
+
+	if err != nil {
+		return err
+	}
+
+	d.SetId(sid)
+	return resourceHaResourceRead(d, meta)
+}
+
+func resourceHaResourceRead(d *schema.ResourceData, meta interface{}) (err error) {
+	var (
+		resource map[string]interface{}
+		vmResId  string
+		vmId     int
+		group    string
+		comment  string
+	)
+
+	pconf := meta.(*providerConfiguration)
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	if resource, err = apiFindInList("/cluster/ha/resources", "sid", d.Id()); err != nil {
+		goto End
+	}
+
+	// deleted outside of terraform
+	if resource == nil {
+		d.SetId("")
+		goto End
+	}
+
+	// imported, look the guest up to get its resource id
+	if d.Get("vm").(string) == "" {
+		if vmId, err = parseHaSid(d.Id()); err != nil {
+			goto End
+		}
+		if vmResId, err = findResourceId(vmId); err != nil {
+			goto End
+		}
+		if vmResId == "" {
+			err = fmt.Errorf("Guest %d of %s not found", vmId, d.Id())
+			goto End
+		}
+		d.Set("vm", vmResId)
+	}
+
+	group, _ = resource["group"].(string)
+	comment, _ = resource["comment"].(string)
+
+	d.Set("state", resource["state"])
+	d.Set("group", group)
+	d.Set("comment", strings.TrimSpace(comment))
+	d.Set("max_restart", haResourceLimit(resource["max_restart"]))
+	d.Set("max_relocate", haResourceLimit(resource["max_relocate"]))
+
+End:
+	pmParallelEnd(pconf)
+	return
+}
+
+func resourceHaResourceUpdate(d *schema.ResourceData, meta interface{}) (err error) {
+	pconf := meta.(*providerConfiguration)
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	_, err = apiPut("/cluster/ha/resources/"+d.Id(), haResourceParams(d, true))
+
+	pmParallelEnd(pconf)
+
+	if err != nil {
+		return err
+	}
+
+	return resourceHaResourceRead(d, meta)
+}
+
+func resourceHaResourceDelete(d *schema.ResourceData, meta interface{}) (err error) {
+	pconf := meta.(*providerConfiguration)
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	_, err = apiDelete("/cluster/ha/resources/"+d.Id(), nil)
+
+	pmParallelEnd(pconf)
+	return
+}
+
+// parameters for create and update. The limits are always sent, as 0 is a
+// valid value for them and the defaults are 1.
+func haResourceParams(d *schema.ResourceData, update bool) map[string]interface{} {
+	var (
+		deleted []string
+		params  = map[string]interface{}{
+			"state":        d.Get("state").(string),
+			"max_restart":  d.Get("max_restart").(int),
+			"max_relocate": d.Get("max_relocate").(int),
+		}
+	)
+
+	for _, k := range []string{"comment", "group"} {
+		if value := d.Get(k).(string); value != "" {
+			params[k] = value
+		} else if update {
+			deleted = append(deleted, k)
+		}
+	}
+
+	if len(deleted) > 0 {
+		params["delete"] = strings.Join(deleted, ",")
+	}
+	return params
+}
+
+// the limits are left out of the config when they have their default value
+func haResourceLimit(v interface{}) int {
+	if v == nil {
+		return 1
+	}
+	return apiInt(v)
+}
+
+// HA id of a guest from its resource id, or "" for invalid ids
+func haResourceSid(resId string) string {
+	_, vmType, vmId, err := parseResourceId(resId)
+	if err != nil {
+		return ""
+	}
+	return haSid(vmType, vmId)
+}
+
+func haSid(vmType string, vmId int) string {
+	if vmType == "lxc" {
+		return fmt.Sprintf("ct:%d", vmId)
+	}
+	return fmt.Sprintf("vm:%d", vmId)
+}
+
+// the vmId of a sid, as in vm:100 or ct:100
+func parseHaSid(sid string) (int, error) {
+	sidParts := strings.SplitN(sid, ":", 2)
+	if len(sidParts) == 2 && (sidParts[0] == "vm" || sidParts[0] == "ct") {
+		if vmId, err := strconv.Atoi(sidParts[1]); err == nil {
+			return vmId, nil
+		}
+	}
+	return -1, fmt.Errorf("Invalid resource format: %s. Must be vm:vmId or ct:vmId", sid)
+}
+
+// remove a guest from HA if it's managed by it, as proxmox refuses to destroy
+// HA managed guests
+func haResourceRemove(vm *pxapi.Vm) error {
+	sid := haSid(vm.Type(), vm.Id())
+
+	resource, err := apiFindInList("/cluster/ha/resources", "sid", sid)
+	if err != nil || resource == nil {
+		return err
+	}
+
+	log.Printf("[DEBUG] removing %s from HA", sid)
+	_, err = apiDelete("/cluster/ha/resources/"+sid, nil)
+	return err
+}
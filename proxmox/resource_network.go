@@ -0,0 +1,357 @@
+package proxmox
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+)
+
+// Linux bridges, VLAN interfaces and bonds of a node. The three share most of
+// their attributes and all of their logic, each one adding its own fields.
+// Changes are written to the pending network config of the node, and applied
+// with a network reload right away.
+
+func resourceNetworkBridge() *schema.Resource {
+	return resourceNetworkInterface("bridge",
+		map[string]*schema.Schema{
+			"ports": {
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"vlan_aware": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+		},
+		map[string]string{
+			"ports":      "bridge_ports",
+			"vlan_aware": "bridge_vlan_aware",
+		},
+	)
+}
+
+func resourceNetworkVlan() *schema.Resource {
+	return resourceNetworkInterface("vlan",
+		map[string]*schema.Schema{
+			"vlan_raw_device": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Interface the VLAN runs on, needed for names like vlan100 but not for eno1.100",
+			},
+			"vlan_id": {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				Description: "VLAN tag, needed for names like vlan100 but not for eno1.100",
+			},
+		},
+		map[string]string{
+			"vlan_raw_device": "vlan-raw-device",
+			"vlan_id":         "vlan-id",
+		},
+	)
+}
+
+func resourceNetworkBond() *schema.Resource {
+	return resourceNetworkInterface("bond",
+		map[string]*schema.Schema{
+			"slaves": {
+				Type:     schema.TypeList,
+				Required: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"bond_mode": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Default:  "balance-rr",
+				ValidateFunc: validation.StringInSlice([]string{
+					"balance-rr", "active-backup", "balance-xor", "broadcast", "802.3ad", "balance-tlb", "balance-alb",
+				}, false),
+			},
+			"bond_primary": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Primary slave in active-backup mode",
+			},
+			"hash_policy": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringInSlice([]string{"layer2", "layer2+3", "layer3+4"}, false),
+			},
+		},
+		map[string]string{
+			"slaves":       "slaves",
+			"bond_mode":    "bond_mode",
+			"bond_primary": "bond-primary",
+			"hash_policy":  "bond_xmit_hash_policy",
+		},
+	)
+}
+
+// build a network interface resource of type ifaceType, adding ifaceSchema
+// to the common attributes. ifaceFields maps attribute names to api ones.
+func resourceNetworkInterface(
+	ifaceType string,
+	ifaceSchema map[string]*schema.Schema,
+	ifaceFields map[string]string,
+) *schema.Resource {
+	fields := map[string]string{
+		"autostart": "autostart",
+		"cidr":      "cidr",
+		"gateway":   "gateway",
+		"cidr6":     "cidr6",
+		"gateway6":  "gateway6",
+		"mtu":       "mtu",
+		"comment":   "comments",
+	}
+	for k, v := range ifaceFields {
+		fields[k] = v
+	}
+
+	resourceSchema := map[string]*schema.Schema{
+		"target_node": {
+			Type:     schema.TypeString,
+			Required: true,
+			ForceNew: true,
+		},
+		"name": {
+			Type:     schema.TypeString,
+			Required: true,
+			ForceNew: true,
+		},
+		"autostart": {
+			Type:     schema.TypeBool,
+			Optional: true,
+			Default:  true,
+		},
+		"cidr": {
+			Type:     schema.TypeString,
+			Optional: true,
+		},
+		"gateway": {
+			Type:     schema.TypeString,
+			Optional: true,
+		},
+		"cidr6": {
+			Type:     schema.TypeString,
+			Optional: true,
+		},
+		"gateway6": {
+			Type:     schema.TypeString,
+			Optional: true,
+		},
+		"mtu": {
+			Type:     schema.TypeInt,
+			Optional: true,
+		},
+		"comment": {
+			Type:     schema.TypeString,
+			Optional: true,
+		},
+		"active": {
+			Type:     schema.TypeBool,
+			Computed: true,
+		},
+		"pending_changes": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "Diff of the network config of the node not applied yet",
+		},
+	}
+	for k, v := range ifaceSchema {
+		resourceSchema[k] = v
+	}
+
+	return &schema.Resource{
+		Create: func(d *schema.ResourceData, meta interface{}) error {
+			return resourceNetworkInterfaceCreate(d, meta, ifaceType, resourceSchema, fields)
+		},
+		Read: func(d *schema.ResourceData, meta interface{}) error {
+			return resourceNetworkInterfaceRead(d, meta, resourceSchema, fields)
+		},
+		Update: func(d *schema.ResourceData, meta interface{}) error {
+			return resourceNetworkInterfaceUpdate(d, meta, ifaceType, resourceSchema, fields)
+		},
+		Delete: resourceNetworkInterfaceDelete,
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+
+		Schema: resourceSchema,
+	}
+}
+
+func resourceNetworkInterfaceCreate(
+	d *schema.ResourceData,
+	meta interface{},
+	ifaceType string,
+	resourceSchema map[string]*schema.Schema,
+	fields map[string]string,
+) (err error) {
+	var (
+		pconf  = meta.(*providerConfiguration)
+		node   = d.Get("target_node").(string)
+		iface  = d.Get("name").(string)
+		params = apiParams(d, fields, " ", false)
+	)
+
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	params["iface"] = iface
+	params["type"] = ifaceType
+
+	if _, err = apiPost(fmt.Sprintf("/nodes/%s/network", node), params); err != nil {
+		goto End
+	}
+
+	d.SetId(networkInterfaceId(node, iface))
+
+	err = networkApply(node)
+
+End:
+	pmParallelEnd(pconf)
+
+	if err != nil {
+		return err
+	}
+
+	return resourceNetworkInterfaceRead(d, meta, resourceSchema, fields)
+}
+
+func resourceNetworkInterfaceRead(
+	d *schema.ResourceData,
+	meta interface{},
+	resourceSchema map[string]*schema.Schema,
+	fields map[string]string,
+) (err error) {
+	var (
+		node, iface string
+		resp        map[string]interface{}
+		ifaceConf   map[string]interface{}
+	)
+
+	pconf := meta.(*providerConfiguration)
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	if node, iface, err = parseNetworkInterfaceId(d.Id()); err != nil {
+		d.SetId("")
+		goto End
+	}
+
+	// the response has the pending diff next to the interfaces
+	if resp, err = apiGetResponse(fmt.Sprintf("/nodes/%s/network", node)); err != nil {
+		goto End
+	}
+
+	if ifaces, isList := resp["data"].([]interface{}); isList {
+		for _, v := range ifaces {
+			if v, isMap := v.(map[string]interface{}); isMap && v["iface"] == iface {
+				ifaceConf = v
+				break
+			}
+		}
+	}
+
+	if ifaceConf == nil {
+		d.SetId("")
+		goto End
+	}
+
+	d.Set("target_node", node)
+	d.Set("name", iface)
+	d.Set("active", apiBool(ifaceConf["active"]))
+	d.Set("pending_changes", resp["changes"])
+
+	err = setApiFields(d, resourceSchema, fields, ifaceConf)
+
+End:
+	pmParallelEnd(pconf)
+	return
+}
+
+func resourceNetworkInterfaceUpdate(
+	d *schema.ResourceData,
+	meta interface{},
+	ifaceType string,
+	resourceSchema map[string]*schema.Schema,
+	fields map[string]string,
+) (err error) {
+	var (
+		node, iface string
+		params      = apiParams(d, fields, " ", true)
+	)
+
+	pconf := meta.(*providerConfiguration)
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	if node, iface, err = parseNetworkInterfaceId(d.Id()); err != nil {
+		d.SetId("")
+		goto End
+	}
+
+	params["type"] = ifaceType
+
+	if _, err = apiPut(fmt.Sprintf("/nodes/%s/network/%s", node, iface), params); err != nil {
+		goto End
+	}
+
+	err = networkApply(node)
+
+End:
+	pmParallelEnd(pconf)
+
+	if err != nil {
+		return err
+	}
+
+	return resourceNetworkInterfaceRead(d, meta, resourceSchema, fields)
+}
+
+func resourceNetworkInterfaceDelete(d *schema.ResourceData, meta interface{}) (err error) {
+	var node, iface string
+
+	pconf := meta.(*providerConfiguration)
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	if node, iface, err = parseNetworkInterfaceId(d.Id()); err != nil {
+		d.SetId("")
+		goto End
+	}
+
+	if _, err = apiDelete(fmt.Sprintf("/nodes/%s/network/%s", node, iface), nil); err != nil {
+		goto End
+	}
+
+	err = networkApply(node)
+
+End:
+	pmParallelEnd(pconf)
+	return
+}
+
+// reload the network of a node, applying its pending config
+func networkApply(node string) error {
+	log.Printf("[DEBUG] applying network config of node %s", node)
+	_, err := apiPut(fmt.Sprintf("/nodes/%s/network", node), nil)
+	return err
+}
+
+func networkInterfaceId(node string, iface string) string {
+	return node + "/" + iface
+}
+
+func parseNetworkInterfaceId(resId string) (node string, iface string, err error) {
+	idParts := strings.Split(resId, "/")
+	if len(idParts) != 2 {
+		return "", "", fmt.Errorf("Invalid resource format: %s. Must be node/interface", resId)
+	}
+	return idParts[0], idParts[1], nil
+}
@@ -0,0 +1,207 @@
+package proxmox
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+func resourcePool() *schema.Resource {
+	return &schema.Resource{
+		Create: resourcePoolCreate,
+		Read:   resourcePoolRead,
+		Update: resourcePoolUpdate,
+		Delete: resourcePoolDelete,
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"poolid": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"comment": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"detach_members": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Remove the VMs and storages from the pool when destroying it, instead of failing",
+			},
+			"vms": {
+				Type:     schema.TypeSet,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeInt},
+			},
+			"storages": {
+				Type:     schema.TypeSet,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+		},
+	}
+}
+
+func resourcePoolCreate(d *schema.ResourceData, meta interface{}) (err error) {
+	var (
+		pconf  = meta.(*providerConfiguration)
+		poolid = d.Get("poolid").(string)
+	)
+
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	_, err = apiPost("/pools", map[string]interface{}{
+		"poolid":  poolid,
+		"comment": d.Get("comment").(string),
+	})
+
+	pmParallelEnd(pconf)
+
+	if err != nil {
+		return err
+	}
+
+	d.SetId(poolid)
+	return resourcePoolRead(d, meta)
+}
+
+func resourcePoolRead(d *schema.ResourceData, meta interface{}) (err error) {
+	var (
+		pool          map[string]interface{}
+		vms, storages []interface{}
+		comment       string
+	)
+
+	pconf := meta.(*providerConfiguration)
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	if pool, err = apiFindInList("/pools", "poolid", d.Id()); err != nil {
+		goto End
+	}
+
+	// deleted outside of terraform
+	if pool == nil {
+		d.SetId("")
+		goto End
+	}
+
+	if pool, err = apiGetMap("/pools/" + d.Id()); err != nil {
+		goto End
+	}
+
+	vms, storages = poolMembers(pool)
+	comment, _ = pool["comment"].(string)
+
+	d.Set("poolid", d.Id())
+	d.Set("comment", strings.TrimSpace(comment))
+	d.Set("vms", vms)
+	d.Set("storages", storages)
+
+End:
+	pmParallelEnd(pconf)
+	return
+}
+
+func resourcePoolUpdate(d *schema.ResourceData, meta interface{}) (err error) {
+	pconf := meta.(*providerConfiguration)
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	if d.HasChange("comment") {
+		_, err = apiPut("/pools/"+d.Id(), map[string]interface{}{
+			"comment": d.Get("comment").(string),
+		})
+	}
+
+	pmParallelEnd(pconf)
+
+	if err != nil {
+		return err
+	}
+
+	return resourcePoolRead(d, meta)
+}
+
+func resourcePoolDelete(d *schema.ResourceData, meta interface{}) (err error) {
+	var (
+		pool          map[string]interface{}
+		vms, storages []interface{}
+		vmList        []string
+		storageList   []string
+	)
+
+	pconf := meta.(*providerConfiguration)
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	if pool, err = apiGetMap("/pools/" + d.Id()); err != nil {
+		goto End
+	}
+
+	vms, storages = poolMembers(pool)
+
+	for _, vmid := range vms {
+		vmList = append(vmList, strconv.Itoa(vmid.(int)))
+	}
+	for _, storage := range storages {
+		storageList = append(storageList, storage.(string))
+	}
+	sort.Strings(vmList)
+	sort.Strings(storageList)
+
+	if len(vmList) > 0 || len(storageList) > 0 {
+		if !d.Get("detach_members").(bool) {
+			err = fmt.Errorf("Pool %s still has members, VMs: [%s] storages: [%s]. Set detach_members=true to remove them from the pool",
+				d.Id(), strings.Join(vmList, ", "), strings.Join(storageList, ", "))
+			goto End
+		}
+
+		log.Printf("[DEBUG] detaching members from pool %s", d.Id())
+		if _, err = apiPut("/pools/"+d.Id(), map[string]interface{}{
+			"vms":     strings.Join(vmList, ","),
+			"storage": strings.Join(storageList, ","),
+			"delete":  1,
+		}); err != nil {
+			goto End
+		}
+	}
+
+	_, err = apiDelete("/pools/"+d.Id(), nil)
+
+End:
+	pmParallelEnd(pconf)
+	return
+}
+
+// split the members of a pool into vmIds and storage ids. Storages are
+// listed once per node by proxmox, and only once here.
+func poolMembers(pool map[string]interface{}) (vms []interface{}, storages []interface{}) {
+	var seenStorages = map[string]bool{}
+
+	members, _ := pool["members"].([]interface{})
+
+	for _, v := range members {
+		member, isMap := v.(map[string]interface{})
+		if !isMap {
+			continue
+		}
+
+		if member["type"] != "storage" {
+			vms = append(vms, apiInt(member["vmid"]))
+		} else if storage := fmt.Sprint(member["storage"]); !seenStorages[storage] {
+			seenStorages[storage] = true
+			storages = append(storages, storage)
+		}
+	}
+	return
+}
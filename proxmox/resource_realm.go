@@ -0,0 +1,392 @@
+package proxmox
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+)
+
+// attributes that can be changed on an existing realm
+var realmFields = map[string]string{
+	"comment":      "comment",
+	"default":      "default",
+	"server1":      "server1",
+	"server2":      "server2",
+	"port":         "port",
+	"mode":         "mode",
+	"base_dn":      "base_dn",
+	"bind_dn":      "bind_dn",
+	"domain":       "domain",
+	"filter":       "filter",
+	"group_filter": "group_filter",
+	"issuer_url":   "issuer-url",
+	"client_id":    "client-id",
+	"autocreate":   "autocreate",
+}
+
+// attributes proxmox only takes when the realm is created
+var realmFixedFields = map[string]string{
+	"user_attr":      "user_attr",
+	"username_claim": "username-claim",
+}
+
+// Authentication realms. Which attributes apply depends on the type:
+// ldap: server1, server2, port, mode, base_dn, user_attr, bind_dn, password,
+// filter, group_filter, sync
+// ad: the ldap ones, plus domain
+// openid: issuer_url, client_id, client_key, username_claim, autocreate
+func resourceRealm() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceRealmCreate,
+		Read:   resourceRealmRead,
+		Update: resourceRealmUpdate,
+		Delete: resourceRealmDelete,
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"realm": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"type": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.StringInSlice([]string{"ldap", "ad", "openid"}, false),
+			},
+			"comment": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"default": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Preselect the realm on the login screen",
+			},
+			"server1": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"server2": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"port": {
+				Type:     schema.TypeInt,
+				Optional: true,
+			},
+			"mode": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ValidateFunc: validation.StringInSlice([]string{"ldap", "ldaps", "ldap+starttls"}, false),
+			},
+			"base_dn": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"user_attr": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+			},
+			"bind_dn": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"password": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Sensitive:   true,
+				Description: "Password of bind_dn. It's only sent to proxmox, changes made outside of terraform aren't detected",
+			},
+			"domain": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"filter": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"group_filter": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"issuer_url": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"client_id": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"client_key": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Sensitive:   true,
+				Description: "It's only sent to proxmox, changes made outside of terraform aren't detected",
+			},
+			"username_claim": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+			},
+			"autocreate": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Create users on their first login",
+			},
+			"sync": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				MaxItems:    1,
+				Description: "Default options of the syncs of the realm, used by sync_trigger",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"scope": {
+							Type:         schema.TypeString,
+							Optional:     true,
+							Default:      "both",
+							ValidateFunc: validation.StringInSlice([]string{"users", "groups", "both"}, false),
+						},
+						"enable_new": {
+							Type:     schema.TypeBool,
+							Optional: true,
+							Default:  true,
+						},
+						"remove_vanished": {
+							Type:        schema.TypeSet,
+							Optional:    true,
+							Elem:        &schema.Schema{Type: schema.TypeString},
+							Description: "What to remove of the users and groups gone from the directory: acl, entry, properties",
+						},
+					},
+				},
+			},
+			"sync_trigger": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Sync the realm when this is set or changes, to any value",
+			},
+		},
+	}
+}
+
+func resourceRealmCreate(d *schema.ResourceData, meta interface{}) (err error) {
+	var (
+		pconf  = meta.(*providerConfiguration)
+		realm  = d.Get("realm").(string)
+		params = realmParams(d, false)
+	)
+
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	for k, v := range apiParams(d, realmFixedFields, ",", false) {
+		params[k] = v
+	}
+
+	params["realm"] = realm
+	params["type"] = d.Get("type").(string)
+
+	if _, err = apiPost("/access/domains", params); err != nil {
+		goto End
+	}
+
+	d.SetId(realm)
+
+	if d.Get("sync_trigger").(string) != "" {
+		err = realmSync(d)
+	}
+
+End:
+	pmParallelEnd(pconf)
+
+	if err != nil {
+		return err
+	}
+
+	return resourceRealmRead(d, meta)
+}
+
+func resourceRealmRead(d *schema.ResourceData, meta interface{}) (err error) {
+	var (
+		realm     map[string]interface{}
+		realmType string
+	)
+
+	pconf := meta.(*providerConfiguration)
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	if realm, err = apiFindInList("/access/domains", "realm", d.Id()); err != nil {
+		goto End
+	}
+
+	// deleted outside of terraform
+	if realm == nil {
+		d.SetId("")
+		goto End
+	}
+
+	if realm, err = apiGetMap("/access/domains/" + d.Id()); err != nil {
+		goto End
+	}
+
+	realmType, _ = realm["type"].(string)
+
+	d.Set("realm", d.Id())
+	d.Set("type", realmType)
+
+	if err = setApiFields(d, resourceRealm().Schema, realmFields, realm); err != nil {
+		goto End
+	}
+	if err = setApiFields(d, resourceRealm().Schema, realmFixedFields, realm); err != nil {
+		goto End
+	}
+
+	err = d.Set("sync", realmSyncList(realm["sync-defaults-options"]))
+
+End:
+	pmParallelEnd(pconf)
+	return
+}
+
+func resourceRealmUpdate(d *schema.ResourceData, meta interface{}) (err error) {
+	pconf := meta.(*providerConfiguration)
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	if _, err = apiPut("/access/domains/"+d.Id(), realmParams(d, true)); err != nil {
+		goto End
+	}
+
+	if d.HasChange("sync_trigger") && d.Get("sync_trigger").(string) != "" {
+		err = realmSync(d)
+	}
+
+End:
+	pmParallelEnd(pconf)
+
+	if err != nil {
+		return err
+	}
+
+	return resourceRealmRead(d, meta)
+}
+
+func resourceRealmDelete(d *schema.ResourceData, meta interface{}) (err error) {
+	pconf := meta.(*providerConfiguration)
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	_, err = apiDelete("/access/domains/"+d.Id(), nil)
+
+	pmParallelEnd(pconf)
+	return
+}
+
+// parameters for create and update, adding the secrets and sync options to
+// the realm fields
+func realmParams(d *schema.ResourceData, update bool) map[string]interface{} {
+	params := apiParams(d, realmFields, ",", update)
+
+	for attr, param := range map[string]string{"password": "password", "client_key": "client-key"} {
+		if value := d.Get(attr).(string); value != "" && (!update || d.HasChange(attr)) {
+			params[param] = value
+		}
+	}
+
+	if syncOptions := realmSyncOptions(d); syncOptions != "" {
+		params["sync-defaults-options"] = syncOptions
+	} else if update && d.HasChange("sync") {
+		deleted, _ := params["delete"].(string)
+		params["delete"] = strings.TrimPrefix(deleted+",sync-defaults-options", ",")
+	}
+	return params
+}
+
+// sync the realm with the options in the sync block
+func realmSync(d *schema.ResourceData) error {
+	params := map[string]interface{}{
+		"scope": "both",
+	}
+	for _, option := range strings.Split(realmSyncOptions(d), ",") {
+		if optionParts := strings.SplitN(option, "=", 2); len(optionParts) == 2 {
+			params[optionParts[0]] = optionParts[1]
+		}
+	}
+
+	log.Printf("[DEBUG] syncing realm %s", d.Id())
+	_, err := apiPost("/access/domains/"+d.Id()+"/sync", params)
+	return err
+}
+
+// build the sync-defaults-options value, like
+// scope=both,enable-new=1,remove-vanished=acl;entry
+func realmSyncOptions(d *schema.ResourceData) string {
+	syncList := d.Get("sync").([]interface{})
+	if len(syncList) == 0 || syncList[0] == nil {
+		return ""
+	}
+
+	sync := syncList[0].(map[string]interface{})
+
+	enableNew := 0
+	if sync["enable_new"].(bool) {
+		enableNew = 1
+	}
+	options := []string{
+		"scope=" + sync["scope"].(string),
+		fmt.Sprintf("enable-new=%d", enableNew),
+	}
+
+	var removeVanished []string
+	for _, v := range sync["remove_vanished"].(*schema.Set).List() {
+		removeVanished = append(removeVanished, v.(string))
+	}
+	if len(removeVanished) > 0 {
+		sort.Strings(removeVanished)
+		options = append(options, "remove-vanished="+strings.Join(removeVanished, ";"))
+	}
+
+	return strings.Join(options, ",")
+}
+
+// parse a sync-defaults-options value into the sync block
+func realmSyncList(syncValue interface{}) []interface{} {
+	syncString, _ := syncValue.(string)
+	if syncString == "" {
+		return nil
+	}
+
+	sync := map[string]interface{}{
+		"scope":           "both",
+		"enable_new":      true,
+		"remove_vanished": []string{},
+	}
+	for _, option := range strings.Split(syncString, ",") {
+		optionParts := strings.SplitN(option, "=", 2)
+		if len(optionParts) != 2 {
+			continue
+		}
+		switch optionParts[0] {
+		case "scope":
+			sync["scope"] = optionParts[1]
+		case "enable-new":
+			sync["enable_new"] = apiBool(optionParts[1])
+		case "remove-vanished":
+			sync["remove_vanished"] = apiStringList(optionParts[1])
+		}
+	}
+	return []interface{}{sync}
+}
@@ -0,0 +1,209 @@
+package proxmox
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+var replicationFields = map[string]string{
+	"schedule": "schedule",
+	"rate":     "rate",
+	"comment":  "comment",
+	"disable":  "disable",
+}
+
+// Storage replication jobs of a guest to another node. Jobs are identified as
+// <vmId>-<job>, as in 100-0.
+func resourceReplication() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceReplicationCreate,
+		Read:   resourceReplicationRead,
+		Update: resourceReplicationUpdate,
+		Delete: resourceReplicationDelete,
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"vm": {
+				Type:             schema.TypeString,
+				Required:         true,
+				ForceNew:         true,
+				Description:      "Id of a proxmox_vm_qemu or proxmox_vm_lxc, as in node/qemu/100",
+				DiffSuppressFunc: resourceIdDiffSuppress,
+			},
+			"job": {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				ForceNew:    true,
+				Default:     0,
+				Description: "Number of the job among those of the guest",
+			},
+			"target": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"schedule": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Default:  "*/15",
+			},
+			"rate": {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				Description: "Rate limit in MB/s, 0 for no limit",
+			},
+			"comment": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"disable": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"last_sync": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Time of the last successful sync, as seconds since the epoch",
+			},
+			"next_sync": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+			"fail_count": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+			"error": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Error of the last sync, if it failed",
+			},
+		},
+	}
+}
+
+func resourceReplicationCreate(d *schema.ResourceData, meta interface{}) (err error) {
+	var (
+		vmId  int
+		id    string
+		pconf = meta.(*providerConfiguration)
+	)
+
+	if _, _, vmId, err = parseResourceId(d.Get("vm").(string)); err != nil {
+		return err
+	}
+
+	id = fmt.Sprintf("%d-%d", vmId, d.Get("job").(int))
+	params := apiParams(d, replicationFields, ",", false)
+	params["id"] = id
+	params["type"] = "local"
+	params["target"] = d.Get("target").(string)
+
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	_, err = apiPost("/cluster/replication", params)
+
+	pmParallelEnd(pconf)
+
+	if err != nil {
+		return err
+	}
+
+	d.SetId(id)
+	return resourceReplicationRead(d, meta)
+}
+
+func resourceReplicationRead(d *schema.ResourceData, meta interface{}) (err error) {
+	var (
+		vmResId, node, jobError string
+		job, status             map[string]interface{}
+	)
+
+	pconf := meta.(*providerConfiguration)
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	if job, err = apiFindInList("/cluster/replication", "id", d.Id()); err != nil {
+		goto End
+	}
+
+	// deleted outside of terraform, or already being removed
+	if job == nil || job["remove_job"] != nil {
+		d.SetId("")
+		goto End
+	}
+
+	// the state of the job is kept by the node the guest is on
+	if vmResId, err = findResourceId(apiInt(job["guest"])); err != nil {
+		goto End
+	}
+
+	// the guest was deleted, and the job with it
+	if vmResId == "" {
+		d.SetId("")
+		goto End
+	}
+	if node, _, _, err = parseResourceId(vmResId); err != nil {
+		goto End
+	}
+	if status, err = apiFindInList(fmt.Sprintf("/nodes/%s/replication", node), "id", d.Id()); err != nil {
+		goto End
+	}
+
+	d.Set("vm", vmResId)
+	d.Set("job", apiInt(job["jobnum"]))
+	d.Set("target", job["target"])
+
+	if err = setApiFields(d, resourceReplication().Schema, replicationFields, job); err != nil {
+		goto End
+	}
+	if job["schedule"] == nil {
+		d.Set("schedule", "*/15")
+	}
+
+	jobError, _ = status["error"].(string)
+
+	d.Set("last_sync", apiInt(status["last_sync"]))
+	d.Set("next_sync", apiInt(status["next_sync"]))
+	d.Set("fail_count", apiInt(status["fail_count"]))
+	d.Set("error", strings.TrimSpace(jobError))
+
+End:
+	pmParallelEnd(pconf)
+	return
+}
+
+func resourceReplicationUpdate(d *schema.ResourceData, meta interface{}) (err error) {
+	pconf := meta.(*providerConfiguration)
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	_, err = apiPut("/cluster/replication/"+d.Id(), apiParams(d, replicationFields, ",", true))
+
+	pmParallelEnd(pconf)
+
+	if err != nil {
+		return err
+	}
+
+	return resourceReplicationRead(d, meta)
+}
+
+// Proxmox only marks the job for removal, the replicated volumes on the target
+// being deleted by the next run of the job
+func resourceReplicationDelete(d *schema.ResourceData, meta interface{}) (err error) {
+	pconf := meta.(*providerConfiguration)
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	_, err = apiDelete("/cluster/replication/"+d.Id(), nil)
+
+	pmParallelEnd(pconf)
+	return
+}
@@ -0,0 +1,122 @@
+package proxmox
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+func resourceRole() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceRoleCreate,
+		Read:   resourceRoleRead,
+		Update: resourceRoleUpdate,
+		Delete: resourceRoleDelete,
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"roleid": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"privileges": {
+				Type:        schema.TypeSet,
+				Required:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "Privileges such as VM.Allocate or Datastore.AllocateSpace",
+			},
+		},
+	}
+}
+
+func resourceRoleCreate(d *schema.ResourceData, meta interface{}) (err error) {
+	var (
+		pconf  = meta.(*providerConfiguration)
+		roleid = d.Get("roleid").(string)
+	)
+
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	_, err = apiPost("/access/roles", map[string]interface{}{
+		"roleid": roleid,
+		"privs":  rolePrivileges(d),
+	})
+
+	pmParallelEnd(pconf)
+
+	if err != nil {
+		return err
+	}
+
+	d.SetId(roleid)
+	return resourceRoleRead(d, meta)
+}
+
+func resourceRoleRead(d *schema.ResourceData, meta interface{}) (err error) {
+	var role map[string]interface{}
+
+	pconf := meta.(*providerConfiguration)
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	if role, err = apiFindInList("/access/roles", "roleid", d.Id()); err != nil {
+		goto End
+	}
+
+	// deleted outside of terraform
+	if role == nil {
+		d.SetId("")
+		goto End
+	}
+
+	d.Set("roleid", d.Id())
+	err = d.Set("privileges", apiStringList(role["privs"]))
+
+End:
+	pmParallelEnd(pconf)
+	return
+}
+
+func resourceRoleUpdate(d *schema.ResourceData, meta interface{}) (err error) {
+	pconf := meta.(*providerConfiguration)
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	// without append the privileges are replaced
+	_, err = apiPut("/access/roles/"+d.Id(), map[string]interface{}{
+		"privs": rolePrivileges(d),
+	})
+
+	pmParallelEnd(pconf)
+
+	if err != nil {
+		return err
+	}
+
+	return resourceRoleRead(d, meta)
+}
+
+func resourceRoleDelete(d *schema.ResourceData, meta interface{}) (err error) {
+	pconf := meta.(*providerConfiguration)
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	_, err = apiDelete("/access/roles/"+d.Id(), nil)
+
+	pmParallelEnd(pconf)
+	return
+}
+
+func rolePrivileges(d *schema.ResourceData) string {
+	var privileges []string
+	for _, privilege := range d.Get("privileges").(*schema.Set).List() {
+		privileges = append(privileges, privilege.(string))
+	}
+	sort.Strings(privileges)
+	return strings.Join(privileges, ",")
+}
@@ -0,0 +1,210 @@
+package proxmox
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+)
+
+var sdnSubnetFields = map[string]string{
+	"gateway": "gateway",
+	"snat":    "snat",
+}
+
+// Subnets of an SDN vnet. Proxmox names them after the zone and the network,
+// as in zone1-10.0.0.0-24, here they're identified by vnet and cidr instead.
+func resourceSdnSubnet() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceSdnSubnetCreate,
+		Read:   resourceSdnSubnetRead,
+		Update: resourceSdnSubnetUpdate,
+		Delete: resourceSdnSubnetDelete,
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"vnet": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"cidr": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.IsCIDR,
+			},
+			"gateway": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"snat": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Masquerade the traffic leaving the subnet",
+			},
+		},
+	}
+}
+
+func resourceSdnSubnetCreate(d *schema.ResourceData, meta interface{}) (err error) {
+	var (
+		pconf  = meta.(*providerConfiguration)
+		vnet   = d.Get("vnet").(string)
+		cidr   = d.Get("cidr").(string)
+		params = apiParams(d, sdnSubnetFields, ",", false)
+	)
+
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	params["subnet"] = cidr
+	params["type"] = "subnet"
+
+	if _, err = apiPost("/cluster/sdn/vnets/"+vnet+"/subnets", params); err != nil {
+		goto End
+	}
+
+	d.SetId(sdnSubnetId(vnet, cidr))
+
+	err = sdnApply()
+
+End:
+	pmParallelEnd(pconf)
+
+	if err != nil {
+		return err
+	}
+
+	return resourceSdnSubnetRead(d, meta)
+}
+
+func resourceSdnSubnetRead(d *schema.ResourceData, meta interface{}) (err error) {
+	var (
+		vnet, cidr string
+		subnet     map[string]interface{}
+	)
+
+	pconf := meta.(*providerConfiguration)
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	if vnet, cidr, err = parseSdnSubnetId(d.Id()); err != nil {
+		d.SetId("")
+		goto End
+	}
+
+	if subnet, err = sdnFindSubnet(vnet, cidr); err != nil {
+		goto End
+	}
+
+	// deleted outside of terraform, by itself or with the vnet
+	if subnet == nil {
+		d.SetId("")
+		goto End
+	}
+
+	d.Set("vnet", vnet)
+	d.Set("cidr", cidr)
+
+	err = setApiFields(d, resourceSdnSubnet().Schema, sdnSubnetFields, subnet)
+
+End:
+	pmParallelEnd(pconf)
+	return
+}
+
+func resourceSdnSubnetUpdate(d *schema.ResourceData, meta interface{}) (err error) {
+	var (
+		vnet, cidr string
+		subnet     map[string]interface{}
+	)
+
+	pconf := meta.(*providerConfiguration)
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	if vnet, cidr, err = parseSdnSubnetId(d.Id()); err != nil {
+		goto End
+	}
+
+	if subnet, err = sdnFindSubnet(vnet, cidr); err != nil {
+		goto End
+	}
+	if subnet == nil {
+		err = fmt.Errorf("Subnet %s of vnet %s not found", cidr, vnet)
+		goto End
+	}
+
+	if _, err = apiPut(
+		fmt.Sprintf("/cluster/sdn/vnets/%s/subnets/%s", vnet, subnet["subnet"]),
+		apiParams(d, sdnSubnetFields, ",", true),
+	); err != nil {
+		goto End
+	}
+
+	err = sdnApply()
+
+End:
+	pmParallelEnd(pconf)
+
+	if err != nil {
+		return err
+	}
+
+	return resourceSdnSubnetRead(d, meta)
+}
+
+func resourceSdnSubnetDelete(d *schema.ResourceData, meta interface{}) (err error) {
+	var (
+		vnet, cidr string
+		subnet     map[string]interface{}
+	)
+
+	pconf := meta.(*providerConfiguration)
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	if vnet, cidr, err = parseSdnSubnetId(d.Id()); err != nil {
+		goto End
+	}
+
+	if subnet, err = sdnFindSubnet(vnet, cidr); err != nil || subnet == nil {
+		goto End
+	}
+
+	if _, err = apiDelete(fmt.Sprintf("/cluster/sdn/vnets/%s/subnets/%s", vnet, subnet["subnet"]), nil); err != nil {
+		goto End
+	}
+
+	err = sdnApply()
+
+End:
+	pmParallelEnd(pconf)
+	return
+}
+
+// return the subnet of vnet with the given cidr, or nil when there's none
+func sdnFindSubnet(vnet string, cidr string) (map[string]interface{}, error) {
+	found, err := apiFindInList("/cluster/sdn/vnets", "vnet", vnet)
+	if err != nil || found == nil {
+		return nil, err
+	}
+	return apiFindInList("/cluster/sdn/vnets/"+vnet+"/subnets", "cidr", cidr)
+}
+
+func sdnSubnetId(vnet string, cidr string) string {
+	return vnet + "/" + cidr
+}
+
+func parseSdnSubnetId(resId string) (vnet string, cidr string, err error) {
+	idParts := strings.SplitN(resId, "/", 2)
+	if len(idParts) != 2 || idParts[0] == "" || idParts[1] == "" {
+		return "", "", fmt.Errorf("Invalid resource format: %s. Must be vnet/cidr", resId)
+	}
+	return idParts[0], idParts[1], nil
+}
@@ -0,0 +1,141 @@
+package proxmox
+
+import (
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+)
+
+var sdnVnetFields = map[string]string{
+	"zone":      "zone",
+	"tag":       "tag",
+	"alias":     "alias",
+	"vlanaware": "vlanaware",
+}
+
+// SDN vnets, the networks of a zone that guests attach to by using the vnet
+// as the bridge of their network devices
+func resourceSdnVnet() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceSdnVnetCreate,
+		Read:   resourceSdnVnetRead,
+		Update: resourceSdnVnetUpdate,
+		Delete: resourceSdnVnetDelete,
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"vnet": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.StringMatch(rxSdnId, "must be up to 8 lowercase letters and digits, starting with a letter"),
+			},
+			"zone": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"tag": {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				Description: "VLAN tag or VXLAN id, for the zones other than simple",
+			},
+			"alias": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"vlanaware": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+		},
+	}
+}
+
+func resourceSdnVnetCreate(d *schema.ResourceData, meta interface{}) (err error) {
+	var (
+		pconf  = meta.(*providerConfiguration)
+		vnet   = d.Get("vnet").(string)
+		params = apiParams(d, sdnVnetFields, ",", false)
+	)
+
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	params["vnet"] = vnet
+
+	if _, err = apiPost("/cluster/sdn/vnets", params); err != nil {
+		goto End
+	}
+
+	d.SetId(vnet)
+
+	err = sdnApply()
+
+End:
+	pmParallelEnd(pconf)
+
+	if err != nil {
+		return err
+	}
+
+	return resourceSdnVnetRead(d, meta)
+}
+
+func resourceSdnVnetRead(d *schema.ResourceData, meta interface{}) (err error) {
+	var vnet map[string]interface{}
+
+	pconf := meta.(*providerConfiguration)
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	if vnet, err = apiFindInList("/cluster/sdn/vnets", "vnet", d.Id()); err != nil {
+		goto End
+	}
+
+	// deleted outside of terraform
+	if vnet == nil {
+		d.SetId("")
+		goto End
+	}
+
+	d.Set("vnet", d.Id())
+
+	err = setApiFields(d, resourceSdnVnet().Schema, sdnVnetFields, vnet)
+
+End:
+	pmParallelEnd(pconf)
+	return
+}
+
+func resourceSdnVnetUpdate(d *schema.ResourceData, meta interface{}) (err error) {
+	pconf := meta.(*providerConfiguration)
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	if _, err = apiPut("/cluster/sdn/vnets/"+d.Id(), apiParams(d, sdnVnetFields, ",", true)); err == nil {
+		err = sdnApply()
+	}
+
+	pmParallelEnd(pconf)
+
+	if err != nil {
+		return err
+	}
+
+	return resourceSdnVnetRead(d, meta)
+}
+
+func resourceSdnVnetDelete(d *schema.ResourceData, meta interface{}) (err error) {
+	pconf := meta.(*providerConfiguration)
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	if _, err = apiDelete("/cluster/sdn/vnets/"+d.Id(), nil); err == nil {
+		err = sdnApply()
+	}
+
+	pmParallelEnd(pconf)
+	return
+}
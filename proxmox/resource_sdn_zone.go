@@ -0,0 +1,183 @@
+package proxmox
+
+import (
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+)
+
+var sdnZoneFields = map[string]string{
+	"nodes":         "nodes",
+	"mtu":           "mtu",
+	"bridge":        "bridge",
+	"tag":           "tag",
+	"vlan_protocol": "vlan-protocol",
+	"peers":         "peers",
+	"controller":    "controller",
+	"vrf_vxlan":     "vrf-vxlan",
+	"ipam":          "ipam",
+}
+
+// SDN zones. Which attributes apply depends on the type:
+// vlan: bridge
+// qinq: bridge, tag, vlan_protocol
+// vxlan: peers
+// evpn: controller, vrf_vxlan
+func resourceSdnZone() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceSdnZoneCreate,
+		Read:   resourceSdnZoneRead,
+		Update: resourceSdnZoneUpdate,
+		Delete: resourceSdnZoneDelete,
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"zone": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.StringMatch(rxSdnId, "must be up to 8 lowercase letters and digits, starting with a letter"),
+			},
+			"type": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.StringInSlice([]string{"simple", "vlan", "qinq", "vxlan", "evpn"}, false),
+			},
+			"nodes": {
+				Type:        schema.TypeSet,
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "Nodes the zone is deployed to, all of them when empty",
+			},
+			"mtu": {
+				Type:     schema.TypeInt,
+				Optional: true,
+			},
+			"bridge": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Node bridge the VLANs run on",
+			},
+			"tag": {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				Description: "Service VLAN tag",
+			},
+			"vlan_protocol": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringInSlice([]string{"802.1q", "802.1ad"}, false),
+			},
+			"peers": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "Addresses of the nodes in the VXLAN",
+			},
+			"controller": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"vrf_vxlan": {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				Description: "VXLAN id of the VRF",
+			},
+			"ipam": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+		},
+	}
+}
+
+func resourceSdnZoneCreate(d *schema.ResourceData, meta interface{}) (err error) {
+	var (
+		pconf  = meta.(*providerConfiguration)
+		zone   = d.Get("zone").(string)
+		params = apiParams(d, sdnZoneFields, ",", false)
+	)
+
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	params["zone"] = zone
+	params["type"] = d.Get("type").(string)
+
+	if _, err = apiPost("/cluster/sdn/zones", params); err != nil {
+		goto End
+	}
+
+	d.SetId(zone)
+
+	err = sdnApply()
+
+End:
+	pmParallelEnd(pconf)
+
+	if err != nil {
+		return err
+	}
+
+	return resourceSdnZoneRead(d, meta)
+}
+
+func resourceSdnZoneRead(d *schema.ResourceData, meta interface{}) (err error) {
+	var zone map[string]interface{}
+
+	pconf := meta.(*providerConfiguration)
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	if zone, err = apiFindInList("/cluster/sdn/zones", "zone", d.Id()); err != nil {
+		goto End
+	}
+
+	// deleted outside of terraform
+	if zone == nil {
+		d.SetId("")
+		goto End
+	}
+
+	d.Set("zone", d.Id())
+	d.Set("type", zone["type"])
+
+	err = setApiFields(d, resourceSdnZone().Schema, sdnZoneFields, zone)
+
+End:
+	pmParallelEnd(pconf)
+	return
+}
+
+func resourceSdnZoneUpdate(d *schema.ResourceData, meta interface{}) (err error) {
+	pconf := meta.(*providerConfiguration)
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	if _, err = apiPut("/cluster/sdn/zones/"+d.Id(), apiParams(d, sdnZoneFields, ",", true)); err == nil {
+		err = sdnApply()
+	}
+
+	pmParallelEnd(pconf)
+
+	if err != nil {
+		return err
+	}
+
+	return resourceSdnZoneRead(d, meta)
+}
+
+func resourceSdnZoneDelete(d *schema.ResourceData, meta interface{}) (err error) {
+	pconf := meta.(*providerConfiguration)
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	if _, err = apiDelete("/cluster/sdn/zones/"+d.Id(), nil); err == nil {
+		err = sdnApply()
+	}
+
+	pmParallelEnd(pconf)
+	return
+}
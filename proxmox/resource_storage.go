@@ -0,0 +1,344 @@
+package proxmox
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+)
+
+// attributes that can be changed on an existing storage
+var storageFields = map[string]string{
+	"content":  "content",
+	"nodes":    "nodes",
+	"shared":   "shared",
+	"disable":  "disable",
+	"monhost":  "monhost",
+	"username": "username",
+	"krbd":     "krbd",
+}
+
+// attributes proxmox only takes when the storage is created
+var storageFixedFields = map[string]string{
+	"path":     "path",
+	"server":   "server",
+	"export":   "export",
+	"share":    "share",
+	"domain":   "domain",
+	"vgname":   "vgname",
+	"thinpool": "thinpool",
+	"pool":     "pool",
+}
+
+// keep-* options of prune-backups, in the order proxmox applies them
+var storagePruneOptions = []string{"keep_last", "keep_hourly", "keep_daily", "keep_weekly", "keep_monthly", "keep_yearly"}
+
+// An entry in the storage configuration of the cluster. Which attributes
+// apply depends on the type:
+// dir: path
+// nfs: server, export
+// cifs: server, share, domain, username, password
+// lvm: vgname
+// lvmthin: vgname, thinpool
+// zfspool: pool
+// rbd: pool, monhost, username, krbd
+func resourceStorage() *schema.Resource {
+	storageSchema := map[string]*schema.Schema{
+		"storage": {
+			Type:     schema.TypeString,
+			Required: true,
+			ForceNew: true,
+		},
+		"type": {
+			Type:         schema.TypeString,
+			Required:     true,
+			ForceNew:     true,
+			ValidateFunc: validation.StringInSlice([]string{"dir", "nfs", "cifs", "lvm", "lvmthin", "zfspool", "rbd"}, false),
+		},
+		"content": {
+			Type:        schema.TypeSet,
+			Optional:    true,
+			Computed:    true,
+			Elem:        &schema.Schema{Type: schema.TypeString},
+			Description: "Content types such as images, rootdir, iso, vztmpl, backup and snippets",
+		},
+		"nodes": {
+			Type:        schema.TypeSet,
+			Optional:    true,
+			Elem:        &schema.Schema{Type: schema.TypeString},
+			Description: "Nodes the storage is available on, all of them when empty",
+		},
+		"shared": {
+			Type:     schema.TypeBool,
+			Optional: true,
+			Computed: true,
+		},
+		"disable": {
+			Type:     schema.TypeBool,
+			Optional: true,
+			Default:  false,
+		},
+		"path": {
+			Type:     schema.TypeString,
+			Optional: true,
+			Computed: true,
+			ForceNew: true,
+		},
+		"server": {
+			Type:     schema.TypeString,
+			Optional: true,
+			ForceNew: true,
+		},
+		"export": {
+			Type:     schema.TypeString,
+			Optional: true,
+			ForceNew: true,
+		},
+		"share": {
+			Type:     schema.TypeString,
+			Optional: true,
+			ForceNew: true,
+		},
+		"domain": {
+			Type:     schema.TypeString,
+			Optional: true,
+			ForceNew: true,
+		},
+		"username": {
+			Type:     schema.TypeString,
+			Optional: true,
+		},
+		"password": {
+			Type:        schema.TypeString,
+			Optional:    true,
+			Sensitive:   true,
+			Description: "Only sent to proxmox, changes made outside of terraform aren't detected",
+		},
+		"vgname": {
+			Type:     schema.TypeString,
+			Optional: true,
+			ForceNew: true,
+		},
+		"thinpool": {
+			Type:     schema.TypeString,
+			Optional: true,
+			ForceNew: true,
+		},
+		"pool": {
+			Type:     schema.TypeString,
+			Optional: true,
+			ForceNew: true,
+		},
+		"monhost": {
+			Type:     schema.TypeSet,
+			Optional: true,
+			Elem:     &schema.Schema{Type: schema.TypeString},
+		},
+		"krbd": {
+			Type:     schema.TypeBool,
+			Optional: true,
+			Default:  false,
+		},
+		"prune_backups": storagePruneSchema(),
+	}
+
+	return &schema.Resource{
+		Create: resourceStorageCreate,
+		Read:   resourceStorageRead,
+		Update: resourceStorageUpdate,
+		Delete: resourceStorageDelete,
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+
+		Schema: storageSchema,
+	}
+}
+
+func resourceStorageCreate(d *schema.ResourceData, meta interface{}) (err error) {
+	var (
+		pconf   = meta.(*providerConfiguration)
+		storage = d.Get("storage").(string)
+		params  = apiParams(d, storageFields, ",", false)
+	)
+
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	for k, v := range apiParams(d, storageFixedFields, ",", false) {
+		params[k] = v
+	}
+
+	params["storage"] = storage
+	params["type"] = d.Get("type").(string)
+
+	if password := d.Get("password").(string); password != "" {
+		params["password"] = password
+	}
+
+	if prune := storagePruneParam(d); prune != "" {
+		params["prune-backups"] = prune
+	}
+
+	log.Printf("[DEBUG] creating %s storage %s", params["type"], storage)
+	if _, err = apiPost("/storage", params); err != nil {
+		goto End
+	}
+
+	d.SetId(storage)
+
+End:
+	pmParallelEnd(pconf)
+
+	if err != nil {
+		return err
+	}
+
+	return resourceStorageRead(d, meta)
+}
+
+func resourceStorageRead(d *schema.ResourceData, meta interface{}) (err error) {
+	var (
+		storageConf map[string]interface{}
+		storageType string
+	)
+
+	pconf := meta.(*providerConfiguration)
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	if storageConf, err = apiFindInList("/storage", "storage", d.Id()); err != nil {
+		goto End
+	}
+
+	// deleted outside of terraform
+	if storageConf == nil {
+		d.SetId("")
+		goto End
+	}
+
+	storageType, _ = storageConf["type"].(string)
+
+	d.Set("storage", d.Id())
+	d.Set("type", storageType)
+
+	if err = setApiFields(d, resourceStorage().Schema, storageFields, storageConf); err != nil {
+		goto End
+	}
+	if err = setApiFields(d, resourceStorage().Schema, storageFixedFields, storageConf); err != nil {
+		goto End
+	}
+
+	err = d.Set("prune_backups", storagePruneList(d, storageConf["prune-backups"]))
+
+End:
+	pmParallelEnd(pconf)
+	return
+}
+
+func resourceStorageUpdate(d *schema.ResourceData, meta interface{}) (err error) {
+	var params = apiParams(d, storageFields, ",", true)
+
+	pconf := meta.(*providerConfiguration)
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	if d.HasChange("password") {
+		params["password"] = d.Get("password").(string)
+	}
+
+	if prune := storagePruneParam(d); prune != "" {
+		params["prune-backups"] = prune
+	} else if d.HasChange("prune_backups") {
+		deleted, _ := params["delete"].(string)
+		params["delete"] = strings.TrimPrefix(deleted+",prune-backups", ",")
+	}
+
+	_, err = apiPut("/storage/"+d.Id(), params)
+
+	pmParallelEnd(pconf)
+
+	if err != nil {
+		return err
+	}
+
+	return resourceStorageRead(d, meta)
+}
+
+func resourceStorageDelete(d *schema.ResourceData, meta interface{}) (err error) {
+	pconf := meta.(*providerConfiguration)
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	_, err = apiDelete("/storage/"+d.Id(), nil)
+
+	pmParallelEnd(pconf)
+	return
+}
+
+// prune_backups block, with one attribute per keep-* option
+func storagePruneSchema() *schema.Schema {
+	pruneSchema := map[string]*schema.Schema{}
+	for _, k := range storagePruneOptions {
+		pruneSchema[k] = &schema.Schema{
+			Type:     schema.TypeInt,
+			Optional: true,
+		}
+	}
+
+	return &schema.Schema{
+		Type:     schema.TypeList,
+		Optional: true,
+		MaxItems: 1,
+		Elem: &schema.Resource{
+			Schema: pruneSchema,
+		},
+	}
+}
+
+// build the prune-backups value, like keep-last=3,keep-weekly=2
+func storagePruneParam(d *schema.ResourceData) string {
+	var options []string
+
+	pruneList := d.Get("prune_backups").([]interface{})
+	if len(pruneList) == 0 || pruneList[0] == nil {
+		return ""
+	}
+
+	prune := pruneList[0].(map[string]interface{})
+	for _, k := range storagePruneOptions {
+		if keep := prune[k].(int); keep > 0 {
+			options = append(options, fmt.Sprintf("%s=%d", strings.Replace(k, "_", "-", 1), keep))
+		}
+	}
+	return strings.Join(options, ",")
+}
+
+// parse a prune-backups value into the prune_backups block. An empty block
+// sends no value, so it's read back as it is while it stays in the config.
+func storagePruneList(d *schema.ResourceData, pruneValue interface{}) []interface{} {
+	pruneString, _ := pruneValue.(string)
+	if pruneString == "" {
+		if len(d.Get("prune_backups").([]interface{})) > 0 {
+			return []interface{}{map[string]interface{}{}}
+		}
+		return nil
+	}
+
+	prune := map[string]interface{}{}
+	for _, option := range strings.Split(pruneString, ",") {
+		optionParts := strings.SplitN(option, "=", 2)
+		if len(optionParts) != 2 {
+			continue
+		}
+		k := strings.Replace(optionParts[0], "-", "_", 1)
+		for _, pruneOption := range storagePruneOptions {
+			if k == pruneOption {
+				prune[k] = apiInt(optionParts[1])
+			}
+		}
+	}
+	return []interface{}{prune}
+}
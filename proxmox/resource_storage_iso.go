@@ -0,0 +1,300 @@
+package proxmox
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"log"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	pxapi "github.com/3coma3/proxmox-api-go/proxmox"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+)
+
+// ISO images and container templates on a storage, uploaded from a local file
+// or downloaded by the node from an URL
+func resourceStorageIso() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceStorageIsoCreate,
+		Read:   resourceStorageIsoRead,
+		Delete: resourceStorageIsoDelete,
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"target_node": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"storage": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"content": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				Default:      "iso",
+				ValidateFunc: validation.StringInSlice([]string{"iso", "vztmpl"}, false),
+			},
+			"filename": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				ForceNew:    true,
+				Description: "Name of the file on the storage, defaults to the name in source or url",
+			},
+			"source": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				ForceNew:      true,
+				ConflictsWith: []string{"url"},
+				Description:   "Local file to upload",
+			},
+			"url": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				ForceNew:      true,
+				ConflictsWith: []string{"source"},
+				Description:   "URL the node downloads the file from",
+			},
+			"verify_certificates": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				ForceNew: true,
+				Default:  true,
+			},
+			"checksum": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+			},
+			"checksum_algorithm": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				Default:      "sha256",
+				ValidateFunc: validation.StringInSlice([]string{"md5", "sha1", "sha224", "sha256", "sha384", "sha512"}, false),
+			},
+			"volid": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"size": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func resourceStorageIsoCreate(d *schema.ResourceData, meta interface{}) (err error) {
+	var (
+		file *os.File
+
+		pconf    = meta.(*providerConfiguration)
+		node     = d.Get("target_node").(string)
+		storage  = d.Get("storage").(string)
+		content  = d.Get("content").(string)
+		filename = d.Get("filename").(string)
+		source   = d.Get("source").(string)
+		fileUrl  = d.Get("url").(string)
+		checksum = strings.ToLower(d.Get("checksum").(string))
+	)
+
+	if source == "" && fileUrl == "" {
+		return fmt.Errorf("Either source or url must be set")
+	}
+
+	// the name of a download is the last element of the url path, leaving
+	// out any query string
+	if filename == "" && source != "" {
+		filename = filepath.Base(source)
+	} else if filename == "" {
+		parsedUrl, urlErr := url.Parse(fileUrl)
+		if urlErr != nil {
+			return urlErr
+		}
+		if filename = path.Base(parsedUrl.Path); filename == "/" || filename == "." {
+			return fmt.Errorf("No filename in url %s, filename must be set", fileUrl)
+		}
+	}
+
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	if source != "" {
+		if file, err = os.Open(source); err != nil {
+			goto End
+		}
+		defer file.Close()
+
+		// the file is local, so check it before sending it
+		if checksum != "" {
+			if err = verifyChecksum(file, d.Get("checksum_algorithm").(string), checksum); err != nil {
+				goto End
+			}
+			if _, err = file.Seek(0, io.SeekStart); err != nil {
+				goto End
+			}
+		}
+
+		log.Printf("[DEBUG] uploading %s to %s:%s/%s", source, storage, content, filename)
+		if err = pxapi.GetClient().Upload(node, storage, content, filename, file); err != nil {
+			goto End
+		}
+	} else {
+		params := map[string]interface{}{
+			"url":                 fileUrl,
+			"content":             content,
+			"filename":            filename,
+			"verify-certificates": 0,
+		}
+		if d.Get("verify_certificates").(bool) {
+			params["verify-certificates"] = 1
+		}
+
+		// the node checks the download
+		if checksum != "" {
+			params["checksum"] = checksum
+			params["checksum-algorithm"] = d.Get("checksum_algorithm").(string)
+		}
+
+		log.Printf("[DEBUG] downloading %s to %s:%s/%s", fileUrl, storage, content, filename)
+		if _, err = apiPost(fmt.Sprintf("/nodes/%s/storage/%s/download-url", node, storage), params); err != nil {
+			goto End
+		}
+	}
+
+	d.SetId(storageVolumeId(node, fmt.Sprintf("%s:%s/%s", storage, content, filename)))
+
+End:
+	pmParallelEnd(pconf)
+
+	if err != nil {
+		return err
+	}
+
+	return resourceStorageIsoRead(d, meta)
+}
+
+func resourceStorageIsoRead(d *schema.ResourceData, meta interface{}) (err error) {
+	var (
+		node, volid, storage string
+		volume               map[string]interface{}
+	)
+
+	pconf := meta.(*providerConfiguration)
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	if node, volid, err = parseStorageVolumeId(d.Id()); err != nil {
+		d.SetId("")
+		goto End
+	}
+
+	storage = volumeStorage(volid)
+
+	if volume, err = findStorageVolume(node, volid); err != nil {
+		goto End
+	}
+
+	// deleted outside of terraform
+	if volume == nil {
+		d.SetId("")
+		goto End
+	}
+
+	d.Set("target_node", node)
+	d.Set("storage", storage)
+	d.Set("volid", volid)
+	d.Set("content", volume["content"])
+	d.Set("filename", path.Base(volid))
+	if size, ok := volume["size"].(float64); ok {
+		d.Set("size", int(size))
+	}
+
+End:
+	pmParallelEnd(pconf)
+	return
+}
+
+func resourceStorageIsoDelete(d *schema.ResourceData, meta interface{}) (err error) {
+	var node, volid string
+
+	pconf := meta.(*providerConfiguration)
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	if node, volid, err = parseStorageVolumeId(d.Id()); err != nil {
+		d.SetId("")
+		goto End
+	}
+
+	_, err = apiDelete(fmt.Sprintf("/nodes/%s/storage/%s/content/%s", node, volumeStorage(volid), url.PathEscape(volid)), nil)
+
+End:
+	pmParallelEnd(pconf)
+	return
+}
+
+// return the volume of a storage as seen from node, or nil when it's missing
+func findStorageVolume(node string, volid string) (map[string]interface{}, error) {
+	return apiFindInList(fmt.Sprintf("/nodes/%s/storage/%s/content", node, volumeStorage(volid)), "volid", volid)
+}
+
+// volumes are identified by node and volid, as in node/local:iso/debian.iso
+func storageVolumeId(node string, volid string) string {
+	return node + "/" + volid
+}
+
+func parseStorageVolumeId(resId string) (node string, volid string, err error) {
+	idParts := strings.SplitN(resId, "/", 2)
+	if len(idParts) != 2 || volumeStorage(idParts[1]) == "" {
+		return "", "", fmt.Errorf("Invalid resource format: %s. Must be node/storage:content/file", resId)
+	}
+	return idParts[0], idParts[1], nil
+}
+
+func verifyChecksum(file io.Reader, algorithm string, checksum string) error {
+	var h hash.Hash
+
+	switch algorithm {
+	case "md5":
+		h = md5.New()
+	case "sha1":
+		h = sha1.New()
+	case "sha224":
+		h = sha256.New224()
+	case "sha256":
+		h = sha256.New()
+	case "sha384":
+		h = sha512.New384()
+	case "sha512":
+		h = sha512.New()
+	default:
+		return fmt.Errorf("Unsupported checksum algorithm: %s", algorithm)
+	}
+
+	if _, err := io.Copy(h, file); err != nil {
+		return err
+	}
+
+	if sum := hex.EncodeToString(h.Sum(nil)); sum != checksum {
+		return fmt.Errorf("Checksum mismatch: expected %s %s, got %s", algorithm, checksum, sum)
+	}
+	return nil
+}
@@ -0,0 +1,201 @@
+package proxmox
+
+import (
+	"fmt"
+	"log"
+	"path"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+// Snippet files, such as cloud-init documents for cicustom, on a directory
+// based storage with snippets content. The api can't upload snippets, so
+// they're written on the node over ssh (see nodeWriteFile).
+func resourceStorageSnippet() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceStorageSnippetCreate,
+		Read:   resourceStorageSnippetRead,
+		Update: resourceStorageSnippetUpdate,
+		Delete: resourceStorageSnippetDelete,
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"target_node": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"storage": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"filename": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"content": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"volid": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Volume id of the file, to use in cicustom",
+			},
+			"size": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func resourceStorageSnippetCreate(d *schema.ResourceData, meta interface{}) (err error) {
+	var (
+		filePath string
+
+		pconf    = meta.(*providerConfiguration)
+		node     = d.Get("target_node").(string)
+		storage  = d.Get("storage").(string)
+		filename = d.Get("filename").(string)
+	)
+
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	if filePath, err = storageSnippetPath(storage, filename); err != nil {
+		goto End
+	}
+
+	log.Printf("[DEBUG] writing snippet %s on node %s", filePath, node)
+	if err = nodeWriteFile(pconf, node, filePath, d.Get("content").(string)); err != nil {
+		goto End
+	}
+
+	d.SetId(storageVolumeId(node, fmt.Sprintf("%s:snippets/%s", storage, filename)))
+
+End:
+	pmParallelEnd(pconf)
+
+	if err != nil {
+		return err
+	}
+
+	return resourceStorageSnippetRead(d, meta)
+}
+
+// the content can't be read back through the api, so only the presence and
+// size of the file are checked
+func resourceStorageSnippetRead(d *schema.ResourceData, meta interface{}) (err error) {
+	var (
+		node, volid string
+		volume      map[string]interface{}
+	)
+
+	pconf := meta.(*providerConfiguration)
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	if node, volid, err = parseStorageVolumeId(d.Id()); err != nil {
+		d.SetId("")
+		goto End
+	}
+
+	if volume, err = findStorageVolume(node, volid); err != nil {
+		goto End
+	}
+
+	// deleted outside of terraform
+	if volume == nil {
+		d.SetId("")
+		goto End
+	}
+
+	d.Set("target_node", node)
+	d.Set("storage", volumeStorage(volid))
+	d.Set("filename", path.Base(volid))
+	d.Set("volid", volid)
+	d.Set("size", apiInt(volume["size"]))
+
+End:
+	pmParallelEnd(pconf)
+	return
+}
+
+func resourceStorageSnippetUpdate(d *schema.ResourceData, meta interface{}) (err error) {
+	var node, volid, filePath string
+
+	pconf := meta.(*providerConfiguration)
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	if node, volid, err = parseStorageVolumeId(d.Id()); err != nil {
+		goto End
+	}
+	if filePath, err = storageSnippetPath(volumeStorage(volid), path.Base(volid)); err != nil {
+		goto End
+	}
+
+	err = nodeWriteFile(pconf, node, filePath, d.Get("content").(string))
+
+End:
+	pmParallelEnd(pconf)
+
+	if err != nil {
+		return err
+	}
+
+	return resourceStorageSnippetRead(d, meta)
+}
+
+func resourceStorageSnippetDelete(d *schema.ResourceData, meta interface{}) (err error) {
+	var node, volid, filePath string
+
+	pconf := meta.(*providerConfiguration)
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	if node, volid, err = parseStorageVolumeId(d.Id()); err != nil {
+		d.SetId("")
+		goto End
+	}
+	if filePath, err = storageSnippetPath(volumeStorage(volid), path.Base(volid)); err != nil {
+		goto End
+	}
+
+	err = nodeRemoveFile(pconf, node, filePath)
+
+End:
+	pmParallelEnd(pconf)
+	return
+}
+
+// path of a snippet on the nodes, for storages with a path that hold snippets
+func storageSnippetPath(storage string, filename string) (string, error) {
+	storageConf, err := apiFindInList("/storage", "storage", storage)
+	if err != nil {
+		return "", err
+	}
+	if storageConf == nil {
+		return "", fmt.Errorf("No storage %s", storage)
+	}
+
+	storagePath, _ := storageConf["path"].(string)
+	if storagePath == "" {
+		return "", fmt.Errorf("Storage %s has no path to write snippets to", storage)
+	}
+
+	hasSnippets := false
+	for _, content := range apiStringList(storageConf["content"]) {
+		hasSnippets = hasSnippets || content == "snippets"
+	}
+	if !hasSnippets {
+		return "", fmt.Errorf("Storage %s doesn't hold snippets content", storage)
+	}
+
+	return path.Join(storagePath, "snippets", path.Base(filename)), nil
+}
@@ -0,0 +1,192 @@
+package proxmox
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+func resourceUser() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceUserCreate,
+		Read:   resourceUserRead,
+		Update: resourceUserUpdate,
+		Delete: resourceUserDelete,
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"userid": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "User and realm, as in user@pve",
+			},
+			"password": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Sensitive:   true,
+				Description: "Only for the pve realm. It's only sent to proxmox, changes made outside of terraform aren't detected",
+			},
+			"comment": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"email": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"firstname": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"lastname": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"enable": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  true,
+			},
+			"expire": {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				Default:     0,
+				Description: "Expiration as seconds since the epoch, 0 never expires",
+			},
+			"groups": {
+				Type:     schema.TypeSet,
+				Optional: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+		},
+	}
+}
+
+func resourceUserCreate(d *schema.ResourceData, meta interface{}) (err error) {
+	var (
+		pconf  = meta.(*providerConfiguration)
+		userid = d.Get("userid").(string)
+		params = userParams(d)
+	)
+
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	params["userid"] = userid
+	if password := d.Get("password").(string); password != "" {
+		params["password"] = password
+	}
+
+	_, err = apiPost("/access/users", params)
+
+	pmParallelEnd(pconf)
+
+	if err != nil {
+		return err
+	}
+
+	d.SetId(userid)
+	return resourceUserRead(d, meta)
+}
+
+func resourceUserRead(d *schema.ResourceData, meta interface{}) (err error) {
+	var user map[string]interface{}
+
+	pconf := meta.(*providerConfiguration)
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	if user, err = apiFindInList("/access/users", "userid", d.Id()); err != nil {
+		goto End
+	}
+
+	// deleted outside of terraform
+	if user == nil {
+		d.SetId("")
+		goto End
+	}
+
+	if user, err = apiGetMap("/access/users/" + d.Id()); err != nil {
+		goto End
+	}
+
+	d.Set("userid", d.Id())
+	d.Set("enable", apiBool(user["enable"]))
+	d.Set("expire", apiInt(user["expire"]))
+	d.Set("groups", apiStringList(user["groups"]))
+
+	for _, k := range []string{"comment", "email", "firstname", "lastname"} {
+		value, _ := user[k].(string)
+		d.Set(k, value)
+	}
+
+End:
+	pmParallelEnd(pconf)
+	return
+}
+
+func resourceUserUpdate(d *schema.ResourceData, meta interface{}) (err error) {
+	pconf := meta.(*providerConfiguration)
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	if _, err = apiPut("/access/users/"+d.Id(), userParams(d)); err != nil {
+		goto End
+	}
+
+	if d.HasChange("password") {
+		_, err = apiPut("/access/password", map[string]interface{}{
+			"userid":   d.Id(),
+			"password": d.Get("password").(string),
+		})
+	}
+
+End:
+	pmParallelEnd(pconf)
+
+	if err != nil {
+		return err
+	}
+
+	return resourceUserRead(d, meta)
+}
+
+func resourceUserDelete(d *schema.ResourceData, meta interface{}) (err error) {
+	pconf := meta.(*providerConfiguration)
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	_, err = apiDelete("/access/users/"+d.Id(), nil)
+
+	pmParallelEnd(pconf)
+	return
+}
+
+// parameters shared by create and update, all of them sent every time as
+// proxmox has no way to delete the user ones
+func userParams(d *schema.ResourceData) map[string]interface{} {
+	var groups []string
+	for _, group := range d.Get("groups").(*schema.Set).List() {
+		groups = append(groups, group.(string))
+	}
+	sort.Strings(groups)
+
+	enable := 0
+	if d.Get("enable").(bool) {
+		enable = 1
+	}
+
+	return map[string]interface{}{
+		"comment":   d.Get("comment").(string),
+		"email":     d.Get("email").(string),
+		"firstname": d.Get("firstname").(string),
+		"lastname":  d.Get("lastname").(string),
+		"enable":    enable,
+		"expire":    d.Get("expire").(int),
+		"groups":    strings.Join(groups, ","),
+	}
+}
@@ -0,0 +1,202 @@
+package proxmox
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+// API tokens of a user. The secret is only returned by proxmox when the
+// token is created, so it's kept in the state from then on.
+func resourceUserToken() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceUserTokenCreate,
+		Read:   resourceUserTokenRead,
+		Update: resourceUserTokenUpdate,
+		Delete: resourceUserTokenDelete,
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"userid": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"tokenid": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"comment": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"expire": {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				Default:     0,
+				Description: "Expiration as seconds since the epoch, 0 never expires",
+			},
+			"privsep": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     true,
+				Description: "Restrict the token to the privileges given to it with ACLs, instead of those of the user",
+			},
+			"full_tokenid": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Token id to authenticate with, as in user@pve!token",
+			},
+			"value": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Sensitive:   true,
+				Description: "Secret of the token, only known to terraform for the tokens it creates",
+			},
+		},
+	}
+}
+
+func resourceUserTokenCreate(d *schema.ResourceData, meta interface{}) (err error) {
+	var (
+		token map[string]interface{}
+		resp  interface{}
+
+		pconf   = meta.(*providerConfiguration)
+		userid  = d.Get("userid").(string)
+		tokenid = d.Get("tokenid").(string)
+	)
+
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	resp, err = apiPost(userTokenApiPath(userid, tokenid), userTokenParams(d))
+
+	pmParallelEnd(pconf)
+
+	if err != nil {
+		return err
+	}
+
+	d.SetId(userTokenId(userid, tokenid))
+
+	if token, _ = resp.(map[string]interface{}); token != nil {
+		d.Set("value", token["value"])
+	}
+
+	return resourceUserTokenRead(d, meta)
+}
+
+func resourceUserTokenRead(d *schema.ResourceData, meta interface{}) (err error) {
+	var (
+		userid, tokenid, comment string
+		user, token              map[string]interface{}
+	)
+
+	pconf := meta.(*providerConfiguration)
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	if userid, tokenid, err = parseUserTokenId(d.Id()); err != nil {
+		d.SetId("")
+		goto End
+	}
+
+	// listing the tokens of a missing user fails, so look for the user first
+	if user, err = apiFindInList("/access/users", "userid", userid); err != nil {
+		goto End
+	}
+
+	if user != nil {
+		if token, err = apiFindInList("/access/users/"+userid+"/token", "tokenid", tokenid); err != nil {
+			goto End
+		}
+	}
+
+	// deleted outside of terraform, by itself or with the user
+	if token == nil {
+		d.SetId("")
+		goto End
+	}
+
+	comment, _ = token["comment"].(string)
+
+	d.Set("userid", userid)
+	d.Set("tokenid", tokenid)
+	d.Set("full_tokenid", d.Id())
+	d.Set("comment", strings.TrimSpace(comment))
+	d.Set("expire", apiInt(token["expire"]))
+	d.Set("privsep", apiBool(token["privsep"]))
+
+End:
+	pmParallelEnd(pconf)
+	return
+}
+
+func resourceUserTokenUpdate(d *schema.ResourceData, meta interface{}) (err error) {
+	var userid, tokenid string
+
+	pconf := meta.(*providerConfiguration)
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	if userid, tokenid, err = parseUserTokenId(d.Id()); err == nil {
+		_, err = apiPut(userTokenApiPath(userid, tokenid), userTokenParams(d))
+	}
+
+	pmParallelEnd(pconf)
+
+	if err != nil {
+		return err
+	}
+
+	return resourceUserTokenRead(d, meta)
+}
+
+func resourceUserTokenDelete(d *schema.ResourceData, meta interface{}) (err error) {
+	var userid, tokenid string
+
+	pconf := meta.(*providerConfiguration)
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	if userid, tokenid, err = parseUserTokenId(d.Id()); err == nil {
+		_, err = apiDelete(userTokenApiPath(userid, tokenid), nil)
+	}
+
+	pmParallelEnd(pconf)
+	return
+}
+
+func userTokenParams(d *schema.ResourceData) map[string]interface{} {
+	params := map[string]interface{}{
+		"comment": d.Get("comment").(string),
+		"expire":  d.Get("expire").(int),
+		"privsep": 0,
+	}
+	if d.Get("privsep").(bool) {
+		params["privsep"] = 1
+	}
+	return params
+}
+
+func userTokenApiPath(userid string, tokenid string) string {
+	return fmt.Sprintf("/access/users/%s/token/%s", userid, tokenid)
+}
+
+// tokens are identified by their full id, as in user@pve!token
+func userTokenId(userid string, tokenid string) string {
+	return userid + "!" + tokenid
+}
+
+func parseUserTokenId(resId string) (userid string, tokenid string, err error) {
+	sep := strings.LastIndex(resId, "!")
+	if sep < 1 || sep == len(resId)-1 {
+		return "", "", fmt.Errorf("Invalid resource format: %s. Must be user@realm!token", resId)
+	}
+	return resId[:sep], resId[sep+1:], nil
+}
@@ -0,0 +1,233 @@
+package proxmox
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"regexp"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+)
+
+// A vzdump archive of a guest, made when the resource is created and deleted
+// with it. Archives are identified like the other volumes, by node and volid.
+func resourceVmBackup() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceVmBackupCreate,
+		Read:   resourceVmBackupRead,
+		Delete: resourceVmBackupDelete,
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"vm": {
+				Type:             schema.TypeString,
+				Required:         true,
+				ForceNew:         true,
+				Description:      "Id of a proxmox_vm_qemu or proxmox_vm_lxc, as in node/qemu/100",
+				DiffSuppressFunc: resourceIdDiffSuppress,
+			},
+			"storage": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"mode": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				Default:      "snapshot",
+				ValidateFunc: validation.StringInSlice([]string{"snapshot", "suspend", "stop"}, false),
+			},
+			"compress": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				Default:      "zstd",
+				ValidateFunc: validation.StringInSlice([]string{"0", "gzip", "lzo", "zstd"}, false),
+			},
+			"volid": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Volume id of the archive, to use in restore_from",
+			},
+			"size": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func resourceVmBackupCreate(d *schema.ResourceData, meta interface{}) (err error) {
+	var (
+		vmId        int
+		vmResId     string
+		node, volid string
+		before      map[string]bool
+		after       []map[string]interface{}
+		newest      float64
+
+		pconf   = meta.(*providerConfiguration)
+		storage = d.Get("storage").(string)
+	)
+
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	if _, _, vmId, err = parseResourceId(d.Get("vm").(string)); err != nil {
+		goto End
+	}
+
+	// back up on the node the guest is on now
+	if vmResId, err = findResourceId(vmId); err != nil {
+		goto End
+	}
+	if vmResId == "" {
+		err = fmt.Errorf("Guest %d not found", vmId)
+		goto End
+	}
+	if node, _, _, err = parseResourceId(vmResId); err != nil {
+		goto End
+	}
+
+	if after, err = vmBackupVolumes(node, storage, vmId); err != nil {
+		goto End
+	}
+	before = map[string]bool{}
+	for _, volume := range after {
+		before[volume["volid"].(string)] = true
+	}
+
+	// remove=0 keeps vzdump from pruning older archives, as those may be
+	// managed by other resources
+	log.Printf("[DEBUG] backing up guest %d to %s", vmId, storage)
+	if _, err = apiPost(fmt.Sprintf("/nodes/%s/vzdump", node), map[string]interface{}{
+		"vmid":     vmId,
+		"storage":  storage,
+		"mode":     d.Get("mode").(string),
+		"compress": d.Get("compress").(string),
+		"remove":   0,
+	}); err != nil {
+		goto End
+	}
+
+	// the task doesn't return the archive, look for the newest one that
+	// wasn't there before
+	if after, err = vmBackupVolumes(node, storage, vmId); err != nil {
+		goto End
+	}
+	for _, volume := range after {
+		ctime, _ := volume["ctime"].(float64)
+		if !before[volume["volid"].(string)] && ctime >= newest {
+			volid = volume["volid"].(string)
+			newest = ctime
+		}
+	}
+
+	if volid == "" {
+		err = fmt.Errorf("Backup of guest %d finished, but no new archive was found in storage %s", vmId, storage)
+		goto End
+	}
+
+	d.SetId(storageVolumeId(node, volid))
+
+End:
+	pmParallelEnd(pconf)
+
+	if err != nil {
+		return err
+	}
+
+	return resourceVmBackupRead(d, meta)
+}
+
+func resourceVmBackupRead(d *schema.ResourceData, meta interface{}) (err error) {
+	var (
+		node, volid, vmResId string
+		volume               map[string]interface{}
+	)
+
+	pconf := meta.(*providerConfiguration)
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	if node, volid, err = parseStorageVolumeId(d.Id()); err != nil {
+		d.SetId("")
+		goto End
+	}
+
+	if volume, err = findStorageVolume(node, volid); err != nil {
+		goto End
+	}
+
+	// deleted outside of terraform
+	if volume == nil {
+		d.SetId("")
+		goto End
+	}
+
+	// imported, the guest may be gone while its backups remain, in which
+	// case it's taken as being on the node of the archive
+	if d.Get("vm").(string) == "" {
+		if vmResId, err = findResourceId(apiInt(volume["vmid"])); err != nil {
+			goto End
+		}
+		if vmResId == "" {
+			if match := rxBackupType.FindStringSubmatch(volid); match != nil {
+				vmResId = fmt.Sprintf("%s/%s/%d", node, match[1], apiInt(volume["vmid"]))
+			}
+		}
+		d.Set("vm", vmResId)
+	}
+
+	d.Set("storage", volumeStorage(volid))
+	d.Set("volid", volid)
+	d.Set("size", apiInt(volume["size"]))
+
+End:
+	pmParallelEnd(pconf)
+	return
+}
+
+func resourceVmBackupDelete(d *schema.ResourceData, meta interface{}) (err error) {
+	var node, volid string
+
+	pconf := meta.(*providerConfiguration)
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	if node, volid, err = parseStorageVolumeId(d.Id()); err != nil {
+		d.SetId("")
+		goto End
+	}
+
+	_, err = apiDelete(fmt.Sprintf("/nodes/%s/storage/%s/content/%s", node, volumeStorage(volid), url.PathEscape(volid)), nil)
+
+End:
+	pmParallelEnd(pconf)
+	return
+}
+
+// archives are named after the guest type, as in
+// local:backup/vzdump-qemu-100-2021_01_01-00_00_00.vma.zst
+var rxBackupType = regexp.MustCompile("vzdump-(qemu|lxc)-\\d+-")
+
+// the backup archives of a guest in a storage
+func vmBackupVolumes(node string, storage string, vmId int) (volumes []map[string]interface{}, err error) {
+	list, err := apiGetList(fmt.Sprintf("/nodes/%s/storage/%s/content?content=backup&vmid=%d", node, storage, vmId))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, v := range list {
+		if volume, isMap := v.(map[string]interface{}); isMap {
+			if _, hasVolid := volume["volid"].(string); hasVolid {
+				volumes = append(volumes, volume)
+			}
+		}
+	}
+	return
+}
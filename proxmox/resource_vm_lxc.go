@@ -42,7 +42,7 @@ func resourceVmLxc() *schema.Resource {
 				Type:          schema.TypeString,
 				Optional:      true,
 				ForceNew:      true,
-				ConflictsWith: []string{"ostemplate"},
+				ConflictsWith: []string{"ostemplate", "restore_from"},
 			},
 			"cores": {
 				Type:     schema.TypeInt,
@@ -202,11 +202,18 @@ func resourceVmLxc() *schema.Resource {
 			"ostemplate": {
 				Type:          schema.TypeString,
 				Optional:      true,
-				ConflictsWith: []string{"clone"},
+				ConflictsWith: []string{"clone", "restore_from"},
 				DiffSuppressFunc: func(k, old, new string, d *schema.ResourceData) bool {
 					return d.Id() != ""
 				},
 			},
+			"restore_from": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				ForceNew:      true,
+				ConflictsWith: []string{"clone", "ostemplate"},
+				Description:   "Volume id of a vzdump archive to create the container from, as in local:backup/vzdump-lxc-100.tar.zst",
+			},
 			"password": {
 				Type:     schema.TypeString,
 				Optional: true,
@@ -298,16 +305,23 @@ func resourceVmLxc() *schema.Resource {
 				Required: true,
 				ForceNew: true,
 			},
+			"firewall_security_groups": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "Security groups included in the firewall rules, in order",
+			},
 		},
 	}
 }
 
 func resourceVmLxcCreate(d *schema.ResourceData, meta interface{}) (err error) {
 	var (
-		vmid   int
-		vm     *pxapi.Vm
-		node   *pxapi.Node
-		config = pxapi.NewConfigLxc()
+		vmid      int
+		vm        *pxapi.Vm
+		node      *pxapi.Node
+		apiConfig *pxapi.ConfigLxc
+		config    = pxapi.NewConfigLxc()
 
 		pconf     = meta.(*providerConfiguration)
 		newstatus = d.Get("status").(string)
@@ -369,7 +383,36 @@ func resourceVmLxcCreate(d *schema.ResourceData, meta interface{}) (err error) {
 	}
 	vm.SetNode(node)
 
-	if err = config.CreateVm(vm); err != nil {
+	if archive := d.Get("restore_from").(string); archive != "" {
+		log.Printf("[DEBUG] restoring container %d from %s", vm.Id(), archive)
+
+		// the volumes of the archive are restored to the storage of rootfs
+		if _, err = apiPost(fmt.Sprintf("/nodes/%s/lxc", node.Name()), map[string]interface{}{
+			"vmid":       vm.Id(),
+			"ostemplate": archive,
+			"restore":    1,
+			"storage":    config.Rootfs["storage"],
+			"hostname":   config.Hostname,
+			"unique":     1,
+		}); err != nil {
+			goto End
+		}
+
+		// the archive has the config of the original guest, bring it to
+		// the one set while keeping the restored volumes
+		if apiConfig, err = pxapi.NewConfigLxcFromApi(vm); err != nil {
+			goto End
+		}
+		config.Rootfs["volume"] = apiConfig.Rootfs["volume"]
+		for id, mp := range config.Mp {
+			if apiConfig.Mp[id] != nil {
+				mp["volume"] = apiConfig.Mp[id]["volume"]
+			}
+		}
+		if err = config.UpdateConfig(vm); err != nil {
+			goto End
+		}
+	} else if err = config.CreateVm(vm); err != nil {
 		goto End
 	}
 
@@ -385,11 +428,17 @@ func resourceVmLxcCreate(d *schema.ResourceData, meta interface{}) (err error) {
 	// a non-blank ID tells Terraform that a resource was created
 	d.SetId(resourceId(vm))
 
+	if groups := d.Get("firewall_security_groups").([]interface{}); len(groups) > 0 {
+		err = vmFirewallGroupsApply(vm, groups)
+	}
+
 End:
 	pmParallelEnd(pconf)
 
-	if d.Id() == "" {
-		log.Printf("An error ocurred at creation, and the resource Id is null, signaling destruction. Returning err now.")
+	if err != nil {
+		if d.Id() == "" {
+			log.Printf("An error ocurred at creation, and the resource Id is null, signaling destruction. Returning err now.")
+		}
 		return err
 	}
 
@@ -401,6 +450,7 @@ func resourceVmLxcRead(d *schema.ResourceData, meta interface{}) (err error) {
 		vmid   int
 		vm     *pxapi.Vm
 		config *pxapi.ConfigLxc
+		groups []string
 	)
 
 	pconf := meta.(*providerConfiguration)
@@ -445,6 +495,15 @@ func resourceVmLxcRead(d *schema.ResourceData, meta interface{}) (err error) {
 	d.Set("tty", config.Tty)
 	d.Set("unprivileged", config.Unprivileged)
 
+	// groups are only managed once set, leaving alone those of guests that
+	// don't use the attribute
+	if _, isSet := d.GetOk("firewall_security_groups"); isSet {
+		if groups, err = vmFirewallGroups(vm); err != nil {
+			goto End
+		}
+		d.Set("firewall_security_groups", groups)
+	}
+
 	if err = d.Set("net", updateDevicesSet(d.Get("net").(*schema.Set), config.Net)); err != nil {
 		goto End
 	}
@@ -463,9 +522,13 @@ End:
 
 func resourceVmLxcUpdate(d *schema.ResourceData, meta interface{}) (err error) {
 	var (
-		vmid   int
-		vm     *pxapi.Vm
-		config *pxapi.ConfigLxc
+		vmid        int
+		vm          *pxapi.Vm
+		config      *pxapi.ConfigLxc
+		apiMp       pxapi.VmDevices
+		apiRootfs   pxapi.VmDevice
+		movedMps    []int
+		movedRootfs bool
 
 		pconf     = meta.(*providerConfiguration)
 		newstatus = d.Get("status").(string)
@@ -483,10 +546,18 @@ func resourceVmLxcUpdate(d *schema.ResourceData, meta interface{}) (err error) {
 
 	vm = pxapi.NewVm(vmid)
 
+	if d.HasChange("rootfs") || d.HasChange("mp") {
+		if movedMps, movedRootfs, err = moveVolumes(d, vm); err != nil {
+			goto End
+		}
+	}
+
 	if config, err = pxapi.NewConfigLxcFromApi(vm); err != nil {
 		d.SetId("")
 		goto End
 	}
+	apiMp = config.Mp
+	apiRootfs = config.Rootfs
 
 	config.Ostemplate = d.Get("ostemplate").(string)
 	config.Arch = d.Get("arch").(string)
@@ -513,10 +584,27 @@ func resourceVmLxcUpdate(d *schema.ResourceData, meta interface{}) (err error) {
 	config.Mp = devicesSetToMap(d.Get("mp").(*schema.Set))
 	config.Net = devicesSetToMap(d.Get("net").(*schema.Set))
 
+	// moved volumes keep the volume they were moved to, instead of getting a
+	// new one allocated on the storage
+	if movedRootfs && apiRootfs != nil {
+		config.Rootfs["volume"] = apiRootfs["volume"]
+	}
+	for _, id := range movedMps {
+		if mp, ok := config.Mp[id]; ok && apiMp[id] != nil {
+			mp["volume"] = apiMp[id]["volume"]
+		}
+	}
+
 	if err = config.UpdateConfig(vm); err != nil {
 		goto End
 	}
 
+	if d.HasChange("firewall_security_groups") {
+		if err = vmFirewallGroupsApply(vm, d.Get("firewall_security_groups").([]interface{})); err != nil {
+			goto End
+		}
+	}
+
 	// give sometime to proxmox to catchup
 	time.Sleep(5 * time.Second)
 
@@ -529,7 +617,7 @@ func resourceVmLxcUpdate(d *schema.ResourceData, meta interface{}) (err error) {
 End:
 	pmParallelEnd(pconf)
 
-	if d.Id() == "" {
+	if err != nil {
 		log.Printf("An error ocurred at update. Returning err now.")
 		return err
 	}
@@ -537,6 +625,78 @@ End:
 	return resourceVmLxcRead(d, meta)
 }
 
+// Move the rootfs and the mount points whose storage changed, deleting the
+// source volumes once copied. Proxmox can't move the volumes of a running
+// container, so it's shut down for the moves and started again afterwards.
+// Returns the ids of the mount points moved, and whether the rootfs was.
+func moveVolumes(d *schema.ResourceData, vm *pxapi.Vm) (movedMps []int, movedRootfs bool, err error) {
+	var (
+		running       bool
+		volumeErrors  []string
+		moves         = map[string]string{}
+		oldRootfs, _  = d.GetChange("rootfs")
+		oldMps, _     = d.GetChange("mp")
+		oldRootfsList = oldRootfs.(*schema.Set).List()
+		rootfsList    = d.Get("rootfs").(*schema.Set).List()
+		oldMpMap      = devicesSetToMap(oldMps.(*schema.Set))
+	)
+
+	if len(oldRootfsList) > 0 && len(rootfsList) > 0 {
+		storage := rootfsList[0].(map[string]interface{})["storage"].(string)
+		if storage != oldRootfsList[0].(map[string]interface{})["storage"].(string) {
+			moves["rootfs"] = storage
+			movedRootfs = true
+		}
+	}
+
+	for id, mp := range devicesSetToMap(d.Get("mp").(*schema.Set)) {
+		oldMp, ok := oldMpMap[id]
+		if !ok {
+			continue
+		}
+		storage, oldStorage := volumeStorage(mp["volume"]), volumeStorage(oldMp["volume"])
+		if storage != "" && oldStorage != "" && storage != oldStorage {
+			moves["mp"+strconv.Itoa(id)] = storage
+			movedMps = append(movedMps, id)
+		}
+	}
+
+	if len(moves) == 0 {
+		return
+	}
+
+	if running, err = vmRunning(vm); err != nil {
+		return
+	}
+
+	if running {
+		log.Print("[DEBUG] shutting down container to move its volumes")
+		if _, err = vm.Shutdown(); err != nil {
+			return
+		}
+	}
+
+	for volume, storage := range moves {
+		log.Printf("[DEBUG] moving volume %s to storage %s", volume, storage)
+		if _, moveErr := apiPost(vmApiPath(vm)+"/move_volume", map[string]interface{}{
+			"volume":  volume,
+			"storage": storage,
+			"delete":  1,
+		}); moveErr != nil {
+			volumeErrors = append(volumeErrors, fmt.Sprintf("%s: %v", volume, moveErr))
+		}
+	}
+
+	if running {
+		log.Print("[DEBUG] starting container after moving its volumes")
+		if _, startErr := apiPost(vmApiPath(vm)+"/status/start", nil); startErr != nil {
+			volumeErrors = append(volumeErrors, fmt.Sprintf("restart: %v", startErr))
+		}
+	}
+
+	return movedMps, movedRootfs, devicesError("moving volumes", volumeErrors)
+}
+
 // to debug nested sets
 func printSet(d *schema.ResourceData, s string) {
 	set := d.Get(s).(*schema.Set)
@@ -1,15 +1,18 @@
 package proxmox
 
 import (
+	"encoding/json"
 	"fmt"
 	"log"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
 	pxapi "github.com/3coma3/proxmox-api-go/proxmox"
 	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
 )
 
 func resourceVmQemu() *schema.Resource {
@@ -22,6 +25,7 @@ func resourceVmQemu() *schema.Resource {
 		Importer: &schema.ResourceImporter{
 			State: schema.ImportStatePassthrough,
 		},
+		CustomizeDiff: resourceVmQemuCustomizeDiff,
 
 		Schema: map[string]*schema.Schema{
 			"name": {
@@ -51,14 +55,66 @@ func resourceVmQemu() *schema.Resource {
 				Default:  "1",
 			},
 			"iso": {
-				Type:     schema.TypeString,
-				Optional: true,
-				ForceNew: true,
+				Type:          schema.TypeString,
+				Optional:      true,
+				ForceNew:      true,
+				ConflictsWith: []string{"restore_from"},
 			},
 			"clone": {
-				Type:     schema.TypeString,
-				Optional: true,
-				ForceNew: true,
+				Type:          schema.TypeString,
+				Optional:      true,
+				ForceNew:      true,
+				ConflictsWith: []string{"clone_id", "restore_from"},
+				Description:   "Name of the template to clone from",
+			},
+			"clone_id": {
+				Type:          schema.TypeInt,
+				Optional:      true,
+				ForceNew:      true,
+				ConflictsWith: []string{"clone", "restore_from"},
+				Description:   "vmId of the VM or template to clone from",
+			},
+			"clone_tag": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Only clone from a template with this tag",
+				DiffSuppressFunc: func(k, old, new string, d *schema.ResourceData) bool {
+					return d.Id() != ""
+				},
+			},
+			"clone_prefer_target_node": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "When several templates match, clone from the one on target_node",
+				DiffSuppressFunc: func(k, old, new string, d *schema.ResourceData) bool {
+					return d.Id() != ""
+				},
+			},
+			"full_clone": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				ForceNew:    true,
+				Description: "Full or linked clone, when not set proxmox does a linked clone of templates and a full clone of VMs",
+			},
+			"clone_storage": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				ForceNew:    true,
+				Description: "Storage for the disks of a full clone, instead of the storage of the source",
+			},
+			"clone_format": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				ForceNew:    true,
+				Description: "Format for the disks of a full clone on file based storages",
+			},
+			"restore_from": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				ForceNew:      true,
+				ConflictsWith: []string{"clone", "clone_id", "iso"},
+				Description:   "Volume id of a vzdump archive to create the VM from, as in local:backup/vzdump-qemu-100.vma.zst",
 			},
 			"ostype": {
 				Type:     schema.TypeString,
@@ -66,7 +122,7 @@ func resourceVmQemu() *schema.Resource {
 				Default:  "l26",
 				DiffSuppressFunc: func(k, old, new string, d *schema.ResourceData) bool {
 					if new == "l26" {
-						return len(d.Get("clone").(string)) > 0 // the cloned source may have a different os, which we shoud leave alone
+						return len(d.Get("clone").(string)) > 0 || d.Get("clone_id").(int) != 0 || d.Get("restore_from").(string) != "" // the cloned source may have a different os, which we shoud leave alone
 					}
 					return strings.TrimSpace(old) == strings.TrimSpace(new)
 				},
@@ -143,6 +199,7 @@ func resourceVmQemu() *schema.Resource {
 			"disk": &schema.Schema{
 				Type:     schema.TypeSet,
 				Optional: true,
+				Set:      diskSetHash,
 				Elem: &schema.Resource{
 					Schema: map[string]*schema.Schema{
 						"id": &schema.Schema{
@@ -164,8 +221,17 @@ func resourceVmQemu() *schema.Resource {
 							Description: "One of PVE types as described: https://pve.proxmox.com/wiki/Storage",
 						},
 						"size": &schema.Schema{
-							Type:     schema.TypeString,
-							Required: true,
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: validateDiskSize,
+							DiffSuppressFunc: func(k, old, new string, d *schema.ResourceData) bool {
+								oldSize, err := diskSizeBytes(old)
+								if err != nil {
+									return false
+								}
+								newSize, err := diskSizeBytes(new)
+								return err == nil && oldSize == newSize
+							},
 						},
 						"format": &schema.Schema{
 							Type:     schema.TypeString,
@@ -195,6 +261,12 @@ func resourceVmQemu() *schema.Resource {
 					},
 				},
 			},
+			"delete_unused_disks": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Destroy the volumes of disks removed from the config",
+			},
 			"preprovision_ostype": {
 				Type:     schema.TypeString,
 				Optional: true,
@@ -264,12 +336,74 @@ func resourceVmQemu() *schema.Resource {
 				},
 			},
 			"ipconfig0": {
-				Type:     schema.TypeString,
-				Optional: true,
+				Type:          schema.TypeString,
+				Optional:      true,
+				ConflictsWith: []string{"ipconfig"},
 			},
 			"ipconfig1": {
-				Type:     schema.TypeString,
-				Optional: true,
+				Type:          schema.TypeString,
+				Optional:      true,
+				ConflictsWith: []string{"ipconfig"},
+			},
+			"ipconfig": {
+				Type:          schema.TypeSet,
+				Optional:      true,
+				ConflictsWith: []string{"ipconfig0", "ipconfig1"},
+				Description:   "Cloud-init addresses of the net devices, one block per device",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"index": {
+							Type:         schema.TypeInt,
+							Required:     true,
+							ValidateFunc: validation.IntBetween(0, 31),
+							Description:  "Id of the net device, as in net0",
+						},
+						"ip": {
+							Type:        schema.TypeString,
+							Optional:    true,
+							Description: "IPv4 address in CIDR notation, or dhcp",
+						},
+						"gw": {
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+						"ip6": {
+							Type:        schema.TypeString,
+							Optional:    true,
+							Description: "IPv6 address in CIDR notation, dhcp or auto",
+						},
+						"gw6": {
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+					},
+				},
+			},
+			"cicustom": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				MaxItems:    1,
+				Description: "Snippets replacing the generated cloud-init documents, as volume ids like local:snippets/user.yml",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"user": {
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+						"network": {
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+						"meta": {
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+						"vendor": {
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+					},
+				},
 			},
 			"preprovision": {
 				Type:          schema.TypeBool,
@@ -281,6 +415,25 @@ func resourceVmQemu() *schema.Resource {
 				Type:     schema.TypeString,
 				Optional: true,
 			},
+			"cloudinit_reboot": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "never",
+				ValidateFunc: validation.StringInSlice([]string{"never", "reboot", "stop-start"}, false),
+				Description:  "How a running VM is restarted to see cloud-init changes: never, reboot, or stop-start for a cold boot",
+			},
+			"cloudinit_pending": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "Cloud-init attributes changed that the running VM sees after its next boot",
+			},
+			"firewall_security_groups": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "Security groups included in the firewall rules, in order",
+			},
 		},
 	}
 }
@@ -324,6 +477,11 @@ func resourceVmQemuCreate(d *schema.ResourceData, meta interface{}) (err error)
 	// explicit client passing
 	pconf.Client.Set()
 
+	if ipconfigs := ipconfigParams(d.Get("ipconfig").(*schema.Set)); len(ipconfigs) > 0 {
+		config.Ipconfig0 = ipconfigs[0]
+		config.Ipconfig1 = ipconfigs[1]
+	}
+
 	log.Print("[DEBUG] checking for duplicate name")
 	vm, _ = pxapi.FindVm(config.Name)
 
@@ -350,8 +508,14 @@ func resourceVmQemuCreate(d *schema.ResourceData, meta interface{}) (err error)
 	vm.SetNode(node)
 
 	// check if ISO or clone
-	if d.Get("clone").(string) != "" {
-		if src, err = pxapi.FindVm(d.Get("clone").(string)); err != nil {
+	if d.Get("clone").(string) != "" || d.Get("clone_id").(int) != 0 {
+		if src, err = findCloneSource(
+			d.Get("clone").(string),
+			d.Get("clone_id").(int),
+			d.Get("clone_tag").(string),
+			node.Name(),
+			d.Get("clone_prefer_target_node").(bool),
+		); err != nil {
 			goto End
 		}
 		log.Print("[DEBUG] cloning VM")
@@ -360,14 +524,52 @@ func resourceVmQemuCreate(d *schema.ResourceData, meta interface{}) (err error)
 			"name": config.Name,
 		}
 
+		if fullClone, isSet := d.GetOkExists("full_clone"); isSet {
+			cloneParams["full"] = 0
+			if fullClone.(bool) {
+				cloneParams["full"] = 1
+			}
+		}
+
+		// target storage and format only apply to full clones
+		if storage := d.Get("clone_storage").(string); storage != "" {
+			cloneParams["storage"] = storage
+		}
+		if format := d.Get("clone_format").(string); format != "" {
+			cloneParams["format"] = format
+		}
+		if cloneParams["storage"] != nil || cloneParams["format"] != nil {
+			if cloneParams["full"] == 0 {
+				err = fmt.Errorf("clone_storage and clone_format can't be used with full_clone = false")
+				goto End
+			}
+			cloneParams["full"] = 1
+		}
+
+		// the source may live on another node, usually on shared storage
+		if src.Node().Name() != node.Name() {
+			cloneParams["target"] = node.Name()
+		}
+
 		if _, err = src.Clone(vm.Id(), cloneParams); err != nil {
 			goto End
 		}
 
-		// give sometime to proxmox to catchup
-		time.Sleep(5 * time.Second)
+	} else if archive := d.Get("restore_from").(string); archive != "" {
+		log.Printf("[DEBUG] restoring VM %d from %s", vm.Id(), archive)
 
-		if err = prepareDiskSize(vm, qemuDisks); err != nil {
+		// unique regenerates the MAC addresses, as the original guest may
+		// still be around. The disks are restored to the storage of the
+		// first disk, as the storages of the archive may not exist here.
+		restoreParams := map[string]interface{}{
+			"vmid":    vm.Id(),
+			"archive": archive,
+			"unique":  1,
+		}
+		if storage := restoreStorage(qemuDisks); storage != "" {
+			restoreParams["storage"] = storage
+		}
+		if _, err = apiPost(fmt.Sprintf("/nodes/%s/qemu", node.Name()), restoreParams); err != nil {
 			goto End
 		}
 
@@ -377,12 +579,49 @@ func resourceVmQemuCreate(d *schema.ResourceData, meta interface{}) (err error)
 			goto End
 		}
 	} else {
-		return fmt.Errorf("Either clone or iso must be set")
+		err = fmt.Errorf("Either clone, clone_id, iso or restore_from must be set")
+		goto End
 	}
 
+	// a non-blank ID tells Terraform that a resource was created, set it now
+	// so a failure from here on leaves the VM tainted instead of orphaned
+	d.SetId(resourceId(vm))
+
 	// give sometime to proxmox to catchup
 	time.Sleep(5 * time.Second)
 
+	// VMs created from an iso get their disks as configured, cloned and
+	// restored ones have those of their source
+	if config.Iso == "" {
+		if err = prepareDiskSize(vm, qemuDisks); err != nil {
+			goto End
+		}
+	}
+
+	// the archive has the config of the original guest, bring it to the one
+	// set now that the disks are in place
+	if d.Get("restore_from").(string) != "" {
+		if err = config.UpdateConfig(vm); err != nil {
+			goto End
+		}
+	}
+
+	if cicustom := cicustomParam(d); cicustom != "" {
+		if _, err = apiPost(vmApiPath(vm)+"/config", map[string]interface{}{"cicustom": cicustom}); err != nil {
+			goto End
+		}
+	}
+
+	if err = ipconfigApply(vm, d); err != nil {
+		goto End
+	}
+
+	if groups := d.Get("firewall_security_groups").([]interface{}); len(groups) > 0 {
+		if err = vmFirewallGroupsApply(vm, groups); err != nil {
+			goto End
+		}
+	}
+
 	if newstatus != "" {
 		if _, err = vm.SetStatus(newstatus); err != nil {
 			goto End
@@ -393,28 +632,118 @@ func resourceVmQemuCreate(d *schema.ResourceData, meta interface{}) (err error)
 		goto End
 	}
 
-	// a non-blank ID tells Terraform that a resource was created
-	d.SetId(resourceId(vm))
-
 	// Apply pre-provision if enabled.
 	// preprovision(d, pconf, vm, true)
 
 End:
 	pmParallelEnd(pconf)
 
-	if d.Id() == "" {
-		log.Printf("An error ocurred at creation, and the resource Id is null, signaling destruction. Returning err now.")
+	if err != nil {
+		if d.Id() == "" {
+			log.Printf("An error ocurred at creation, and the resource Id is null, signaling destruction. Returning err now.")
+		}
 		return err
 	}
 
 	return resourceVmQemuRead(d, meta)
 }
 
+var cloudinitFields = []string{
+	"ciuser", "cipassword", "searchdomain", "nameserver", "sshkeys",
+	"ipconfig0", "ipconfig1", "ipconfig", "cicustom",
+}
+
+func resourceVmQemuCustomizeDiff(d *schema.ResourceDiff, meta interface{}) (err error) {
+	if err = ipconfigCustomizeDiff(d); err != nil {
+		return err
+	}
+	if d.Id() != "" {
+		return cloudinitPendingDiff(d)
+	}
+	return cloneSourceCustomizeDiff(d, meta)
+}
+
+// Fail at plan time when ipconfig blocks share an index, as only one of them
+// would be applied.
+func ipconfigCustomizeDiff(d *schema.ResourceDiff) error {
+	indexes := map[int]bool{}
+	for _, v := range d.Get("ipconfig").(*schema.Set).List() {
+		index := v.(map[string]interface{})["index"].(int)
+		if indexes[index] {
+			return fmt.Errorf("Duplicate ipconfig index %d", index)
+		}
+		indexes[index] = true
+	}
+	return nil
+}
+
+// Report in the plan the cloud-init attributes that change, which the VM only
+// sees when it boots again, along with those still pending from before.
+func cloudinitPendingDiff(d *schema.ResourceDiff) error {
+	var changed []string
+
+	for _, k := range cloudinitFields {
+		if d.HasChange(k) {
+			changed = append(changed, k)
+		}
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+
+	pending := map[string]bool{}
+	for _, k := range append(apiStringList(d.Get("cloudinit_pending")), changed...) {
+		pending[k] = true
+	}
+
+	var pendingList []string
+	for k := range pending {
+		pendingList = append(pendingList, k)
+	}
+	sort.Strings(pendingList)
+
+	return d.SetNew("cloudinit_pending", pendingList)
+}
+
+// Fail at plan time when the clone source of a new VM is ambiguous. Sources
+// that aren't found are only reported at apply time, as they may be created
+// in the same run.
+func cloneSourceCustomizeDiff(d *schema.ResourceDiff, meta interface{}) (err error) {
+	if d.Id() != "" || !d.NewValueKnown("clone") || !d.NewValueKnown("clone_id") {
+		return nil
+	}
+
+	if d.Get("clone").(string) == "" && d.Get("clone_id").(int) == 0 {
+		return nil
+	}
+
+	pconf := meta.(*providerConfiguration)
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	candidates, err := cloneSourceCandidates(
+		d.Get("clone").(string),
+		d.Get("clone_id").(int),
+		d.Get("clone_tag").(string),
+		d.Get("target_node").(string),
+		d.Get("clone_prefer_target_node").(bool),
+	)
+	if err == nil && len(candidates) > 1 {
+		err = ambiguousCloneSourceError(d.Get("clone").(string), candidates)
+	}
+
+	pmParallelEnd(pconf)
+	return
+}
+
 func resourceVmQemuRead(d *schema.ResourceData, meta interface{}) (err error) {
 	var (
-		vmid   int
-		vm     *pxapi.Vm
-		config *pxapi.ConfigQemu
+		vmid      int
+		vm        *pxapi.Vm
+		config    *pxapi.ConfigQemu
+		apiConfig map[string]interface{}
+		groups    []string
+		pending   []string
 	)
 
 	pconf := meta.(*providerConfiguration)
@@ -449,12 +778,59 @@ func resourceVmQemuRead(d *schema.ResourceData, meta interface{}) (err error) {
 	d.Set("searchdomain", config.Searchdomain)
 	d.Set("nameserver", config.Nameserver)
 	d.Set("sshkeys", config.Sshkeys)
-	d.Set("ipconfig0", config.Ipconfig0)
-	d.Set("ipconfig1", config.Ipconfig1)
+
+	// pxapi doesn't know about cicustom yet, nor about ipconfig past 1
+	if apiConfig, err = apiGetMap(vmApiPath(vm) + "/config"); err != nil {
+		goto End
+	}
+
+	// keep the addresses in the attributes the config uses, preferring the
+	// ipconfig blocks when there's none yet, as on import
+	if d.Get("ipconfig").(*schema.Set).Len() > 0 || (d.Get("ipconfig0").(string) == "" && d.Get("ipconfig1").(string) == "") {
+		if err = d.Set("ipconfig", ipconfigList(apiConfig)); err != nil {
+			goto End
+		}
+		d.Set("ipconfig0", "")
+		d.Set("ipconfig1", "")
+	} else {
+		d.Set("ipconfig", nil)
+		d.Set("ipconfig0", config.Ipconfig0)
+		d.Set("ipconfig1", config.Ipconfig1)
+	}
+	if err = d.Set("cicustom", cicustomList(apiConfig["cicustom"])); err != nil {
+		goto End
+	}
+
+	// without a cloud-init drive there is nothing for the VM to see, and
+	// templates read through here don't have the attribute as they don't boot
+	if _, hasCloudinit := d.Get("cloudinit_reboot").(string); hasCloudinit {
+		if config.HasCloudInit() {
+			if pending, err = cloudinitPendingList(d, vm); err != nil {
+				goto End
+			}
+		}
+		d.Set("cloudinit_pending", pending)
+	}
+
+	// groups are only managed once set, leaving alone those of guests that
+	// don't use the attribute
+	if _, isSet := d.GetOk("firewall_security_groups"); isSet {
+		if groups, err = vmFirewallGroups(vm); err != nil {
+			goto End
+		}
+		d.Set("firewall_security_groups", groups)
+	}
 
 	if err = d.Set("net", updateDevicesSet(d.Get("net").(*schema.Set), config.Net)); err != nil {
 		goto End
 	}
+
+	// the api may return sizes as bare bytes, keep them in the config format
+	for _, disk := range config.Disk {
+		if size, sizeErr := diskSizeBytes(disk["size"]); sizeErr == nil {
+			disk["size"] = formatDiskSize(size)
+		}
+	}
 	err = d.Set("disk", updateDevicesSet(d.Get("disk").(*schema.Set), config.Disk))
 
 End:
@@ -506,18 +882,67 @@ func resourceVmQemuUpdate(d *schema.ResourceData, meta interface{}) (err error)
 	config.Ipconfig0 = d.Get("ipconfig0").(string)
 	config.Ipconfig1 = d.Get("ipconfig1").(string)
 
+	if ipconfigs := ipconfigParams(d.Get("ipconfig").(*schema.Set)); len(ipconfigs) > 0 {
+		config.Ipconfig0 = ipconfigs[0]
+		config.Ipconfig1 = ipconfigs[1]
+	}
+
 	qemuDisks = devicesSetToMap(d.Get("disk").(*schema.Set))
 	config.Disk = qemuDisks
 	config.Net = devicesSetToMap(d.Get("net").(*schema.Set))
 
-	if err = config.UpdateConfig(vm); err != nil {
+	if d.HasChange("disk") {
+		oldDisks, _ := d.GetChange("disk")
+		oldQemuDisks := devicesSetToMap(oldDisks.(*schema.Set))
+
+		if err = removeDisks(vm, oldQemuDisks, qemuDisks, d.Get("delete_unused_disks").(bool)); err != nil {
+			goto End
+		}
+		if err = moveDisks(vm, oldQemuDisks, qemuDisks); err != nil {
+			goto End
+		}
+	}
+
+	// add and grow the disks before writing the config, so the sizes
+	// compared are the ones of the actual volumes
+	if err = prepareDiskSize(vm, qemuDisks); err != nil {
 		goto End
 	}
 
 	// give sometime to proxmox to catchup
 	time.Sleep(5 * time.Second)
 
-	prepareDiskSize(vm, qemuDisks)
+	if err = config.UpdateConfig(vm); err != nil {
+		goto End
+	}
+
+	if d.HasChange("ipconfig") {
+		if err = ipconfigApply(vm, d); err != nil {
+			goto End
+		}
+	}
+
+	if d.HasChange("cicustom") {
+		params := map[string]interface{}{"delete": "cicustom"}
+		if cicustom := cicustomParam(d); cicustom != "" {
+			params = map[string]interface{}{"cicustom": cicustom}
+		}
+		if _, err = apiPost(vmApiPath(vm)+"/config", params); err != nil {
+			goto End
+		}
+	}
+
+	if len(d.Get("cloudinit_pending").([]interface{})) > 0 && config.HasCloudInit() {
+		if err = cloudinitApply(d, vm); err != nil {
+			goto End
+		}
+	}
+
+	if d.HasChange("firewall_security_groups") {
+		if err = vmFirewallGroupsApply(vm, d.Get("firewall_security_groups").([]interface{})); err != nil {
+			goto End
+		}
+	}
 
 	// give sometime to proxmox to catchup
 	time.Sleep(5 * time.Second)
@@ -541,7 +966,7 @@ func resourceVmQemuUpdate(d *schema.ResourceData, meta interface{}) (err error)
 End:
 	pmParallelEnd(pconf)
 
-	if d.Id() == "" {
+	if err != nil {
 		log.Printf("An error ocurred at update. Returning err now.")
 		return err
 	}
@@ -570,6 +995,10 @@ func ResourceVmDelete(d *schema.ResourceData, meta interface{}) (err error) {
 		goto End
 	}
 
+	if err = haResourceRemove(vm); err != nil {
+		goto End
+	}
+
 	if _, err = vm.Shutdown(); err != nil {
 		goto End
 	}
@@ -583,53 +1012,605 @@ End:
 	return
 }
 
-// Increase disk size if original disk was smaller than new disk.
+var ipconfigFields = []string{"ip", "gw", "ip6", "gw6"}
+
+// the ipconfigN options of the ipconfig blocks, by index, as in
+// ip=10.0.0.2/24,gw=10.0.0.1
+func ipconfigParams(ipconfigs *schema.Set) map[int]string {
+	params := map[int]string{}
+
+	for _, v := range ipconfigs.List() {
+		ipconfig := v.(map[string]interface{})
+
+		var options []string
+		for _, k := range ipconfigFields {
+			if value, _ := ipconfig[k].(string); value != "" {
+				options = append(options, k+"="+value)
+			}
+		}
+		params[ipconfig["index"].(int)] = strings.Join(options, ",")
+	}
+	return params
+}
+
+// the ipconfig blocks of the ipconfigN options of a config
+func ipconfigList(apiConfig map[string]interface{}) (ipconfigs []interface{}) {
+	for k, v := range apiConfig {
+		if !strings.HasPrefix(k, "ipconfig") {
+			continue
+		}
+		index, err := strconv.Atoi(strings.TrimPrefix(k, "ipconfig"))
+		if err != nil {
+			continue
+		}
+
+		ipconfig := map[string]interface{}{"index": index}
+		for _, option := range apiStringList(v) {
+			if optionParts := strings.SplitN(option, "=", 2); len(optionParts) == 2 {
+				ipconfig[optionParts[0]] = optionParts[1]
+			}
+		}
+		ipconfigs = append(ipconfigs, ipconfig)
+	}
+	return
+}
+
+// write all the ipconfig blocks, as pxapi only writes the first two and not
+// on clones, and delete the options of the blocks removed. Those still set by
+// ipconfig0 and ipconfig1 are kept.
+func ipconfigApply(vm *pxapi.Vm, d *schema.ResourceData) error {
+	var (
+		deleted         []string
+		oldIpconfigs, _ = d.GetChange("ipconfig")
+		ipconfigs       = ipconfigParams(d.Get("ipconfig").(*schema.Set))
+		params          = map[string]interface{}{}
+	)
+
+	for index, value := range ipconfigs {
+		params[fmt.Sprintf("ipconfig%d", index)] = value
+	}
+
+	for index := range ipconfigParams(oldIpconfigs.(*schema.Set)) {
+		option := fmt.Sprintf("ipconfig%d", index)
+		if _, kept := ipconfigs[index]; kept {
+			continue
+		}
+		if index < 2 && d.Get(option).(string) != "" {
+			continue
+		}
+		deleted = append(deleted, option)
+	}
+
+	if len(deleted) > 0 {
+		sort.Strings(deleted)
+		params["delete"] = strings.Join(deleted, ",")
+	}
+	if len(params) == 0 {
+		return nil
+	}
+
+	_, err := apiPost(vmApiPath(vm)+"/config", params)
+	return err
+}
+
+// Regenerate the cloud-init drive after its attributes changed, and restart
+// the VM as cloudinit_reboot says so it sees them. A stopped VM sees them on
+// its next start.
+func cloudinitApply(d *schema.ResourceData, vm *pxapi.Vm) error {
+	log.Printf("[DEBUG] regenerating cloud-init drive of %s", resourceId(vm))
+	if _, err := apiPut(vmApiPath(vm)+"/cloudinit", nil); err != nil {
+		return err
+	}
+
+	running, err := vmRunning(vm)
+	if err != nil {
+		return err
+	}
+	if !running {
+		return d.Set("cloudinit_pending", nil)
+	}
+
+	switch d.Get("cloudinit_reboot").(string) {
+	case "reboot":
+		log.Printf("[DEBUG] rebooting %s for cloud-init changes", resourceId(vm))
+		_, err = apiPost(vmApiPath(vm)+"/status/reboot", nil)
+	case "stop-start":
+		log.Printf("[DEBUG] stopping and starting %s for cloud-init changes", resourceId(vm))
+		if _, err = apiPost(vmApiPath(vm)+"/status/stop", nil); err != nil {
+			return err
+		}
+		if err = vmWaitStopped(vm); err != nil {
+			return err
+		}
+		_, err = apiPost(vmApiPath(vm)+"/status/start", nil)
+	default:
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return d.Set("cloudinit_pending", nil)
+}
+
+// The cloud-init attributes with changes the running VM hasn't seen, from the
+// pending config, which proxmox keeps until the VM boots again.
+func cloudinitPendingList(d *schema.ResourceData, vm *pxapi.Vm) ([]string, error) {
+	entries, err := apiGetList(vmApiPath(vm) + "/pending")
+	if err != nil {
+		return nil, err
+	}
+
+	pending := map[string]bool{}
+	for _, v := range entries {
+		entry, isMap := v.(map[string]interface{})
+		if !isMap || (entry["pending"] == nil && entry["delete"] == nil) {
+			continue
+		}
+		key, _ := entry["key"].(string)
+		if strings.HasPrefix(key, "ipconfig") && d.Get("ipconfig").(*schema.Set).Len() > 0 {
+			key = "ipconfig"
+		}
+		for _, k := range cloudinitFields {
+			if k == key {
+				pending[k] = true
+			}
+		}
+	}
+
+	var pendingList []string
+	for k := range pending {
+		pendingList = append(pendingList, k)
+	}
+	sort.Strings(pendingList)
+	return pendingList, nil
+}
+
+// build the cicustom option, as in user=local:snippets/user.yml,vendor=...
+func cicustomParam(d *schema.ResourceData) string {
+	var snippets []string
+
+	for _, v := range d.Get("cicustom").([]interface{}) {
+		cicustom, _ := v.(map[string]interface{})
+		for _, k := range []string{"user", "network", "meta", "vendor"} {
+			if volid, _ := cicustom[k].(string); volid != "" {
+				snippets = append(snippets, k+"="+volid)
+			}
+		}
+	}
+	return strings.Join(snippets, ",")
+}
+
+func cicustomList(cicustomValue interface{}) []interface{} {
+	cicustom := map[string]interface{}{}
+
+	for _, snippet := range apiStringList(cicustomValue) {
+		if snippetParts := strings.SplitN(snippet, "=", 2); len(snippetParts) == 2 {
+			cicustom[snippetParts[0]] = snippetParts[1]
+		}
+	}
+	if len(cicustom) == 0 {
+		return nil
+	}
+	return []interface{}{cicustom}
+}
+
+// Find the guest to clone from, by vmId or by name. See cloneSourceCandidates.
+func findCloneSource(
+	name string,
+	vmid int,
+	tag string,
+	targetNode string,
+	preferTargetNode bool,
+) (*pxapi.Vm, error) {
+	candidates, err := cloneSourceCandidates(name, vmid, tag, targetNode, preferTargetNode)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case len(candidates) > 1:
+		return nil, ambiguousCloneSourceError(name, candidates)
+	case len(candidates) == 0 && vmid != 0:
+		return nil, fmt.Errorf("No VM with vmId %d to clone from", vmid)
+	case len(candidates) == 0 && tag != "":
+		return nil, fmt.Errorf("No template named %s with tag %s to clone from", name, tag)
+	case len(candidates) == 0:
+		return nil, fmt.Errorf("No template named %s to clone from", name)
+	}
+
+	node, err := pxapi.FindNode(candidates[0]["node"].(string))
+	if err != nil {
+		return nil, err
+	}
+	src := pxapi.NewVm(int(candidates[0]["vmid"].(float64)))
+	src.SetNode(node)
+	return src, nil
+}
+
+// List the qemu guests of the cluster that can be the clone source. A vmId
+// matches any VM or template, while names only match templates, optionally
+// the ones with the given tag. If preferTargetNode is set and some of the
+// candidates are on targetNode, only those are returned.
+func cloneSourceCandidates(
+	name string,
+	vmid int,
+	tag string,
+	targetNode string,
+	preferTargetNode bool,
+) (candidates []map[string]interface{}, err error) {
+	resources, err := apiGetList("/cluster/resources?type=vm")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, resource := range resources {
+		guest, isMap := resource.(map[string]interface{})
+		if !isMap || guest["type"] != "qemu" {
+			continue
+		}
+
+		if vmid != 0 {
+			if guestId, _ := guest["vmid"].(float64); int(guestId) == vmid {
+				candidates = append(candidates, guest)
+			}
+			continue
+		}
+
+		if template, _ := guest["template"].(float64); template != 1 || guest["name"] != name {
+			continue
+		}
+		if tag != "" && !guestHasTag(guest, tag) {
+			continue
+		}
+		candidates = append(candidates, guest)
+	}
+
+	if preferTargetNode {
+		var onTargetNode []map[string]interface{}
+		for _, guest := range candidates {
+			if guest["node"] == targetNode {
+				onTargetNode = append(onTargetNode, guest)
+			}
+		}
+		if len(onTargetNode) > 0 {
+			candidates = onTargetNode
+		}
+	}
+	return
+}
+
+func guestHasTag(guest map[string]interface{}, tag string) bool {
+	tags, _ := guest["tags"].(string)
+	for _, guestTag := range strings.FieldsFunc(tags, func(r rune) bool {
+		return r == ';' || r == ',' || r == ' '
+	}) {
+		if guestTag == tag {
+			return true
+		}
+	}
+	return false
+}
+
+func ambiguousCloneSourceError(name string, candidates []map[string]interface{}) error {
+	var list []string
+	for _, guest := range candidates {
+		list = append(list, fmt.Sprintf("vmId %v on node %v", guest["vmid"], guest["node"]))
+	}
+	sort.Strings(list)
+	return fmt.Errorf("More than one template named %s to clone from: %s. Use clone_id, clone_tag or clone_prefer_target_node to choose one", name, strings.Join(list, ", "))
+}
+
+// Bring the disks of the vm in line with the configured ones: disks missing
+// on their bus are added and smaller ones are grown. Shrinking is not
+// supported by proxmox, so asking for it is an error. Disks are handled
+// independently and all the failures are reported together.
 func prepareDiskSize(
 	vm *pxapi.Vm,
 	diskConfMap pxapi.VmDevices,
 ) error {
-	clonedConfig, err := pxapi.NewConfigQemuFromApi(vm)
+	vmConfig, err := pxapi.NewConfigQemuFromApi(vm)
 	if err != nil {
 		return err
 	}
-	//log.Printf("%s", clonedConfig)
+
+	var diskErrors []string
 	for diskID, diskConf := range diskConfMap {
 		diskName := fmt.Sprintf("%v%v", diskConf["type"], diskID)
 
-		diskSize := diskSizeGB(diskConf["size"])
+		if err = prepareDisk(vm, diskName, diskConf, vmConfig.Disk[diskID]); err != nil {
+			diskErrors = append(diskErrors, fmt.Sprintf("%s: %v", diskName, err))
+		}
+	}
+
+	return devicesError("preparing disks", diskErrors)
+}
 
-		if _, diskExists := clonedConfig.Disk[diskID]; !diskExists {
+func prepareDisk(
+	vm *pxapi.Vm,
+	diskName string,
+	diskConf pxapi.VmDevice,
+	vmDisk pxapi.VmDevice,
+) error {
+	diskSize, err := diskSizeBytes(diskConf["size"])
+	if err != nil {
+		return err
+	}
+
+	// the id is free, or taken by a disk on another bus
+	if vmDisk == nil || vmDisk["type"] != diskConf["type"] {
+		log.Print("[DEBUG] adding disk " + diskName)
+		if _, err = apiPost(vmApiPath(vm)+"/config", map[string]interface{}{
+			diskName: newDiskParam(diskConf, diskSize),
+		}); err != nil {
 			return err
 		}
+		return pinDiskVolume(vm, diskName, diskConf)
+	}
 
-		clonedDiskSize := diskSizeGB(clonedConfig.Disk[diskID]["size"])
+	vmDiskSize, err := diskSizeBytes(vmDisk["size"])
+	if err != nil {
+		return err
+	}
 
-		if err != nil {
+	if diskSize < vmDiskSize {
+		return fmt.Errorf("Disk has size %s, can't shrink it to %s", formatDiskSize(vmDiskSize), formatDiskSize(diskSize))
+	}
+
+	// ResizeDisk takes the new size in whole GiB
+	if diskSize > vmDiskSize {
+		if diskSize%(1<<30) != 0 {
+			return fmt.Errorf("Disks can only be resized to whole gigabytes, not to %s", formatDiskSize(diskSize))
+		}
+		log.Print("[DEBUG] resizing disk " + diskName)
+		if _, err = vm.ResizeDisk(diskName, strconv.FormatInt(diskSize>>30, 10)); err != nil {
 			return err
 		}
+	}
 
-		if diskSize > clonedDiskSize {
-			log.Print("[DEBUG] resizing disk " + diskName)
-			_, err = vm.ResizeDisk(diskName, strconv.Itoa(int(diskSize)))
-			if err != nil {
-				return err
-			}
-		}
+	// disks already there, like cloned or restored ones, keep their volume too
+	return pinDiskVolume(vm, diskName, diskConf)
+}
+
+// the storage of the disk with the lowest id, or an empty string without disks
+func restoreStorage(diskConfMap pxapi.VmDevices) string {
+	var ids []int
+	for id := range diskConfMap {
+		ids = append(ids, id)
+	}
+	if len(ids) == 0 {
+		return ""
+	}
+	sort.Ints(ids)
+	storage, _ := diskConfMap[ids[0]]["storage"].(string)
+	return storage
+}
+
+// Keep the volume of a disk in its config, so writing the config afterwards
+// refers to it instead of allocating another one
+func pinDiskVolume(vm *pxapi.Vm, diskName string, diskConf pxapi.VmDevice) error {
+	apiConfig, err := apiGetMap(vmApiPath(vm) + "/config")
+	if err != nil {
+		return err
+	}
+
+	diskValue, _ := apiConfig[diskName].(string)
+	volid := strings.SplitN(diskValue, ",", 2)[0]
+	volidParts := strings.SplitN(volid, ":", 2)
+	if len(volidParts) != 2 {
+		return fmt.Errorf("No volume found for the disk, config is %q", diskValue)
 	}
+
+	diskConf["storage"] = volidParts[0]
+	diskConf["file"] = volidParts[1]
 	return nil
 }
 
-func diskSizeGB(dcSize interface{}) float64 {
-	var diskSize float64
-	// TODO support other units M/G/K
-	switch dcSize.(type) {
+// build the value that allocates a new volume for a disk, proxmox takes the
+// size of new volumes in GiB
+func newDiskParam(diskConf pxapi.VmDevice, diskSize int64) string {
+	param := fmt.Sprintf("%v:%s", diskConf["storage"], strconv.FormatFloat(float64(diskSize)/(1<<30), 'f', -1, 64))
+
+	for _, k := range []string{"format", "cache"} {
+		if v, ok := diskConf[k].(string); ok && v != "" {
+			param += "," + k + "=" + v
+		}
+	}
+	for _, k := range []string{"backup", "iothread", "replicate"} {
+		if v, ok := diskConf[k].(bool); ok && v {
+			param += "," + k + "=1"
+		}
+	}
+	return param
+}
+
+var rxUnusedDisk = regexp.MustCompile("^unused\\d+$")
+
+// Detach the disks that were removed from the config, looking at bus and id.
+// Proxmox keeps detached volumes as unusedN entries. With deleteUnused set
+// the ones holding the volumes detached here are destroyed, matched by volid,
+// leaving alone the volumes that were already unused.
+func removeDisks(
+	vm *pxapi.Vm,
+	oldDiskConfMap pxapi.VmDevices,
+	diskConfMap pxapi.VmDevices,
+	deleteUnused bool,
+) error {
+	var detached []string
+	for diskID, oldDiskConf := range oldDiskConfMap {
+		if diskConf, ok := diskConfMap[diskID]; !ok || diskConf["type"] != oldDiskConf["type"] {
+			detached = append(detached, fmt.Sprintf("%v%v", oldDiskConf["type"], diskID))
+		}
+	}
+
+	if len(detached) == 0 {
+		return nil
+	}
+
+	vmConfig, err := apiGetMap(vmApiPath(vm) + "/config")
+	if err != nil {
+		return err
+	}
+
+	// the volumes of the disks detached here, the only unused ones that may
+	// be deleted
+	detachedVolumes := map[string]bool{}
+	for _, diskName := range detached {
+		if diskValue, ok := vmConfig[diskName].(string); ok {
+			detachedVolumes[strings.SplitN(diskValue, ",", 2)[0]] = true
+		}
+	}
+
+	sort.Strings(detached)
+	log.Print("[DEBUG] detaching disks " + strings.Join(detached, ","))
+	if _, err = apiPost(vmApiPath(vm)+"/config", map[string]interface{}{
+		"delete": strings.Join(detached, ","),
+	}); err != nil {
+		return err
+	}
+
+	if !deleteUnused {
+		return nil
+	}
+
+	if vmConfig, err = apiGetMap(vmApiPath(vm) + "/config"); err != nil {
+		return err
+	}
+
+	var unused []string
+	for k, v := range vmConfig {
+		if volid, isString := v.(string); isString && rxUnusedDisk.MatchString(k) && detachedVolumes[volid] {
+			unused = append(unused, k)
+		}
+	}
+
+	if len(unused) == 0 {
+		return nil
+	}
+
+	sort.Strings(unused)
+	log.Print("[DEBUG] deleting unused disks " + strings.Join(unused, ","))
+	_, err = apiPost(vmApiPath(vm)+"/config", map[string]interface{}{
+		"delete": strings.Join(unused, ","),
+	})
+	return err
+}
+
+// Move the disks whose storage or format changed to the new storage, deleting
+// the source volumes once copied. Proxmox does this online for running VMs.
+func moveDisks(
+	vm *pxapi.Vm,
+	oldDiskConfMap pxapi.VmDevices,
+	diskConfMap pxapi.VmDevices,
+) error {
+	var diskErrors []string
+	for diskID, diskConf := range diskConfMap {
+		oldDiskConf, ok := oldDiskConfMap[diskID]
+		if !ok || oldDiskConf["type"] != diskConf["type"] {
+			continue
+		}
+		if oldDiskConf["storage"] == diskConf["storage"] && oldDiskConf["format"] == diskConf["format"] {
+			continue
+		}
+
+		diskName := fmt.Sprintf("%v%v", diskConf["type"], diskID)
+		params := map[string]interface{}{
+			"disk":    diskName,
+			"storage": diskConf["storage"],
+			"delete":  1,
+		}
+		if format, ok := diskConf["format"].(string); ok && format != "" {
+			params["format"] = format
+		}
+
+		log.Printf("[DEBUG] moving disk %s to storage %v", diskName, diskConf["storage"])
+		if _, err := apiPost(vmApiPath(vm)+"/move_disk", params); err != nil {
+			diskErrors = append(diskErrors, fmt.Sprintf("%s: %v", diskName, err))
+			continue
+		}
+
+		// the disk keeps the volume it was moved to
+		if err := pinDiskVolume(vm, diskName, diskConf); err != nil {
+			diskErrors = append(diskErrors, fmt.Sprintf("%s: %v", diskName, err))
+		}
+	}
+
+	return devicesError("moving disks", diskErrors)
+}
+
+var rxDiskSize = regexp.MustCompile("^(\\d+(?:\\.\\d+)?)([KMGT]?)$")
+
+var diskSizeUnits = []struct {
+	suffix string
+	bytes  int64
+}{
+	{"T", 1 << 40},
+	{"G", 1 << 30},
+	{"M", 1 << 20},
+	{"K", 1 << 10},
+	{"", 1},
+}
+
+func validateDiskSize(v interface{}, k string) (ws []string, es []error) {
+	if _, err := diskSizeBytes(v); err != nil {
+		es = append(es, err)
+	}
+	return
+}
+
+// parse a disk size as written in the config or returned by the api
+// into bytes. Strings take an optional K/M/G/T suffix, defaulting to
+// gigabytes as sizes always did in the config, while bare numbers are bytes as
+// in the api
+func diskSizeBytes(dcSize interface{}) (int64, error) {
+	switch size := dcSize.(type) {
 	case string:
-		diskSizeGB := dcSize.(string)
-		diskSize, _ = strconv.ParseFloat(strings.Trim(diskSizeGB, "G"), 64)
+		sizeMatch := rxDiskSize.FindStringSubmatch(strings.ToUpper(strings.TrimSpace(size)))
+		if sizeMatch == nil {
+			return 0, fmt.Errorf("Invalid disk size: %q. Must be a number with an optional K, M, G or T suffix", size)
+		}
+		value, err := strconv.ParseFloat(sizeMatch[1], 64)
+		if err != nil {
+			return 0, err
+		}
+		if sizeMatch[2] == "" {
+			sizeMatch[2] = "G"
+		}
+		for _, unit := range diskSizeUnits {
+			if unit.suffix == sizeMatch[2] {
+				return int64(value * float64(unit.bytes)), nil
+			}
+		}
 	case float64:
-		diskSize = dcSize.(float64)
+		return int64(size), nil
+	case int:
+		return int64(size), nil
 	}
-	return diskSize
+	return 0, fmt.Errorf("Invalid disk size: %v", dcSize)
+}
+
+// format a size in bytes with the largest unit that represents it exactly,
+// the same way proxmox shows sizes in the vm config
+func formatDiskSize(size int64) string {
+	for _, unit := range diskSizeUnits {
+		if size >= unit.bytes && size%unit.bytes == 0 {
+			return strconv.FormatInt(size/unit.bytes, 10) + unit.suffix
+		}
+	}
+	return strconv.FormatInt(size, 10)
+}
+
+// hash disk set members with their size normalized, so equivalent
+// spellings like 32G and 32768M identify the same disk
+func diskSetHash(v interface{}) int {
+	disk := map[string]interface{}{}
+	for k, val := range v.(map[string]interface{}) {
+		disk[k] = val
+	}
+	if size, err := diskSizeBytes(disk["size"]); err == nil {
+		disk["size"] = formatDiskSize(size)
+	}
+	b, _ := json.Marshal(disk)
+	return schema.HashString(string(b))
 }
 
 func initConnInfo(
@@ -646,9 +1627,11 @@ func initConnInfo(
 			sshHost = d.Get("ssh_forward_ip").(string)
 		}
 		if sshHost == "" {
-			// parse IP address out of ipconfig0
-			ipMatch := rxIPconfig.FindStringSubmatch(d.Get("ipconfig0").(string))
-			sshHost = ipMatch[1]
+			// parse IP address out of ipconfig0, which holds the first
+			// ipconfig block when those are used
+			if ipMatch := rxIPconfig.FindStringSubmatch(config.Ipconfig0); ipMatch != nil {
+				sshHost = ipMatch[1]
+			}
 		}
 	} else {
 		log.Print("[DEBUG] setting up SSH forward")
@@ -0,0 +1,253 @@
+package proxmox
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+
+	pxapi "github.com/3coma3/proxmox-api-go/proxmox"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+// A template is built like a proxmox_vm_qemu, or from an existing VM given by
+// convert_vm_id, and then converted. Templates aren't changed in place: any
+// change to the config builds a new one.
+func resourceVmQemuTemplate() *schema.Resource {
+	templateSchema := templateAttributes(resourceVmQemu().Schema)
+
+	// templates don't boot, so cloud-init changes are never pending
+	delete(templateSchema, "cloudinit_reboot")
+	delete(templateSchema, "cloudinit_pending")
+
+	// with convert_vm_id these come from the VM converted
+	for _, k := range []string{"name", "target_node"} {
+		templateSchema[k].Required = false
+		templateSchema[k].Optional = true
+		templateSchema[k].Computed = true
+	}
+
+	// a VM converted can't be built again, so its config is read as it is
+	// instead of differing from the defaults and the config set, which
+	// would replace the template. The suppression covers the blocks too.
+	for _, attr := range templateSchema {
+		if attr.Computed && !attr.Optional {
+			continue
+		}
+		suppress := attr.DiffSuppressFunc
+		attr.DiffSuppressFunc = func(k, old, new string, d *schema.ResourceData) bool {
+			if d.Get("convert_vm_id").(int) != 0 {
+				return true
+			}
+			return suppress != nil && suppress(k, old, new, d)
+		}
+	}
+
+	templateSchema["convert_vm_id"] = &schema.Schema{
+		Type:          schema.TypeInt,
+		Optional:      true,
+		ForceNew:      true,
+		ConflictsWith: []string{"clone", "clone_id", "iso", "restore_from"},
+		Description:   "vmId of an existing VM to convert, instead of creating one",
+	}
+	templateSchema["vmid"] = &schema.Schema{
+		Type:     schema.TypeInt,
+		Computed: true,
+	}
+
+	return &schema.Resource{
+		Create: resourceVmQemuTemplateCreate,
+		Read:   resourceVmQemuTemplateRead,
+		Delete: resourceVmQemuTemplateDelete,
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+		CustomizeDiff: resourceVmQemuTemplateCustomizeDiff,
+
+		Schema: templateSchema,
+	}
+}
+
+// copy the qemu attributes, blocks included, so that any change builds a new
+// template
+func templateAttributes(qemuSchema map[string]*schema.Schema) map[string]*schema.Schema {
+	templateSchema := map[string]*schema.Schema{}
+
+	for k, v := range qemuSchema {
+		attr := *v
+		attr.ForceNew = true
+
+		if elem, isResource := attr.Elem.(*schema.Resource); isResource {
+			elemCopy := *elem
+			elemCopy.Schema = templateAttributes(elem.Schema)
+			attr.Elem = &elemCopy
+		}
+
+		templateSchema[k] = &attr
+	}
+	return templateSchema
+}
+
+func resourceVmQemuTemplateCustomizeDiff(d *schema.ResourceDiff, meta interface{}) (err error) {
+	if err = ipconfigCustomizeDiff(d); err != nil {
+		return err
+	}
+	return cloneSourceCustomizeDiff(d, meta)
+}
+
+func resourceVmQemuTemplateCreate(d *schema.ResourceData, meta interface{}) (err error) {
+	var (
+		vmid    int
+		vm      *pxapi.Vm
+		running bool
+
+		pconf = meta.(*providerConfiguration)
+	)
+
+	if d.Get("convert_vm_id").(int) == 0 {
+		if d.Get("name").(string) == "" || d.Get("target_node").(string) == "" {
+			return fmt.Errorf("name and target_node must be set unless convert_vm_id is")
+		}
+		if err = resourceVmQemuCreate(d, meta); err != nil {
+			return err
+		}
+	}
+
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	if vmid = d.Get("convert_vm_id").(int); vmid == 0 {
+		if _, _, vmid, err = parseResourceId(d.Id()); err != nil {
+			goto End
+		}
+	}
+
+	vm = pxapi.NewVm(vmid)
+
+	if err = vm.Check(); err != nil {
+		goto End
+	}
+
+	d.SetId(resourceId(vm))
+
+	// proxmox only converts stopped VMs
+	if running, err = vmRunning(vm); err != nil {
+		goto End
+	}
+
+	if running {
+		if _, err = vm.Shutdown(); err != nil {
+			goto End
+		}
+	}
+
+	log.Printf("[DEBUG] converting VM %d to template", vmid)
+	_, err = apiPost(vmApiPath(vm)+"/template", nil)
+
+End:
+	pmParallelEnd(pconf)
+
+	if err != nil {
+		return err
+	}
+
+	return resourceVmQemuTemplateRead(d, meta)
+}
+
+func resourceVmQemuTemplateRead(d *schema.ResourceData, meta interface{}) (err error) {
+	var vmid int
+
+	if err = resourceVmQemuRead(d, meta); err != nil || d.Id() == "" {
+		return
+	}
+
+	if _, _, vmid, err = parseResourceId(d.Id()); err != nil {
+		return
+	}
+
+	return d.Set("vmid", vmid)
+}
+
+func resourceVmQemuTemplateDelete(d *schema.ResourceData, meta interface{}) (err error) {
+	var (
+		vmid   int
+		vm     *pxapi.Vm
+		clones []string
+	)
+
+	pconf := meta.(*providerConfiguration)
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	if _, _, vmid, err = parseResourceId(d.Id()); err != nil {
+		d.SetId("")
+		goto End
+	}
+
+	vm = pxapi.NewVm(vmid)
+
+	if err = vm.Check(); err != nil {
+		goto End
+	}
+
+	if clones, err = linkedClones(vm); err != nil {
+		goto End
+	}
+
+	if len(clones) > 0 {
+		err = fmt.Errorf("Template %d still has linked clones: %s", vmid, strings.Join(clones, ", "))
+		goto End
+	}
+
+	if err = haResourceRemove(vm); err != nil {
+		goto End
+	}
+
+	_, err = vm.Delete()
+
+End:
+	pmParallelEnd(pconf)
+	return
+}
+
+var rxDiskKey = regexp.MustCompile("^(ide|sata|scsi|virtio|unused)\\d+$")
+
+// List the qemu guests with disks based on the volumes of a template. The
+// volumes of linked clones name their base, as in
+// local-lvm:base-100-disk-0/vm-101-disk-0
+func linkedClones(template *pxapi.Vm) (clones []string, err error) {
+	resources, err := apiGetList("/cluster/resources?type=vm")
+	if err != nil {
+		return nil, err
+	}
+
+	baseVolume := fmt.Sprintf("base-%d-disk-", template.Id())
+
+	for _, resource := range resources {
+		guest, isMap := resource.(map[string]interface{})
+		if !isMap || guest["type"] != "qemu" {
+			continue
+		}
+
+		guestId, _ := guest["vmid"].(float64)
+		if int(guestId) == template.Id() {
+			continue
+		}
+
+		guestConfig, err := apiGetMap(fmt.Sprintf("/nodes/%v/qemu/%d/config", guest["node"], int(guestId)))
+		if err != nil {
+			return nil, err
+		}
+
+		for k, v := range guestConfig {
+			if volume, isString := v.(string); isString && rxDiskKey.MatchString(k) && strings.Contains(volume, baseVolume) {
+				clones = append(clones, fmt.Sprintf("vmId %d on node %v", int(guestId), guest["node"]))
+				break
+			}
+		}
+	}
+
+	sort.Strings(clones)
+	return
+}
@@ -0,0 +1,273 @@
+package proxmox
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+
+	pxapi "github.com/3coma3/proxmox-api-go/proxmox"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/validation"
+)
+
+var rxSnapshotName = regexp.MustCompile("^[a-zA-Z][a-zA-Z0-9_-]+$")
+
+// Snapshots of a qemu or lxc guest. They're identified by the type and vmId of
+// the guest and the snapshot name, as in qemu/100/before-upgrade, leaving the
+// node out as the guest may move.
+func resourceVmSnapshot() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceVmSnapshotCreate,
+		Read:   resourceVmSnapshotRead,
+		Update: resourceVmSnapshotUpdate,
+		Delete: resourceVmSnapshotDelete,
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"vm": {
+				Type:             schema.TypeString,
+				Required:         true,
+				ForceNew:         true,
+				Description:      "Id of a proxmox_vm_qemu or proxmox_vm_lxc, as in node/qemu/100",
+				DiffSuppressFunc: resourceIdDiffSuppress,
+			},
+			"name": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.StringMatch(rxSnapshotName, "must start with a letter and have only letters, digits, - and _"),
+			},
+			"description": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"vmstate": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				ForceNew:    true,
+				Default:     false,
+				Description: "Include the RAM of a running qemu guest",
+			},
+			"rollback_trigger": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Roll the guest back to the snapshot when this changes, to any value",
+			},
+			"parent": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"snaptime": {
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "Creation time, as seconds since the epoch",
+			},
+		},
+	}
+}
+
+func resourceVmSnapshotCreate(d *schema.ResourceData, meta interface{}) (err error) {
+	var (
+		vmType  string
+		vmId    int
+		vm      *pxapi.Vm
+		params  map[string]interface{}
+		pconf   = meta.(*providerConfiguration)
+		name    = d.Get("name").(string)
+		vmstate = d.Get("vmstate").(bool)
+	)
+
+	if _, vmType, vmId, err = parseResourceId(d.Get("vm").(string)); err != nil {
+		return err
+	}
+	if vmstate && vmType != "qemu" {
+		return fmt.Errorf("vmstate can only be set for qemu guests")
+	}
+
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	if vm, err = vmSnapshotGuest(vmType, vmId); err != nil {
+		goto End
+	}
+
+	params = map[string]interface{}{
+		"snapname": name,
+	}
+	if description := d.Get("description").(string); description != "" {
+		params["description"] = description
+	}
+	if vmstate {
+		params["vmstate"] = 1
+	}
+
+	log.Printf("[DEBUG] taking snapshot %s of %s", name, resourceId(vm))
+	if _, err = apiPost(vmApiPath(vm)+"/snapshot", params); err != nil {
+		goto End
+	}
+
+	d.SetId(fmt.Sprintf("%s/%d/%s", vmType, vmId, name))
+
+End:
+	pmParallelEnd(pconf)
+
+	if err != nil {
+		return err
+	}
+
+	return resourceVmSnapshotRead(d, meta)
+}
+
+func resourceVmSnapshotRead(d *schema.ResourceData, meta interface{}) (err error) {
+	var (
+		vmType, name string
+		vmResId      string
+		vmId         int
+		vm           *pxapi.Vm
+		snapshot     map[string]interface{}
+		parent       string
+		description  string
+	)
+
+	pconf := meta.(*providerConfiguration)
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	if vmType, vmId, name, err = parseVmSnapshotId(d.Id()); err != nil {
+		d.SetId("")
+		goto End
+	}
+
+	if vmResId, err = findResourceId(vmId); err != nil {
+		goto End
+	}
+
+	// deleted outside of terraform, along with the guest
+	if vmResId == "" {
+		d.SetId("")
+		goto End
+	}
+
+	if vm, err = vmSnapshotGuest(vmType, vmId); err != nil {
+		goto End
+	}
+
+	if snapshot, err = apiFindInList(vmApiPath(vm)+"/snapshot", "name", name); err != nil {
+		goto End
+	}
+
+	// deleted outside of terraform
+	if snapshot == nil {
+		d.SetId("")
+		goto End
+	}
+
+	parent, _ = snapshot["parent"].(string)
+	description, _ = snapshot["description"].(string)
+
+	d.Set("vm", resourceId(vm))
+	d.Set("name", name)
+	d.Set("description", strings.TrimSpace(description))
+	d.Set("vmstate", apiBool(snapshot["vmstate"]))
+	d.Set("parent", parent)
+	d.Set("snaptime", apiInt(snapshot["snaptime"]))
+
+End:
+	pmParallelEnd(pconf)
+	return
+}
+
+func resourceVmSnapshotUpdate(d *schema.ResourceData, meta interface{}) (err error) {
+	var (
+		vmType, name string
+		vmId         int
+		vm           *pxapi.Vm
+	)
+
+	pconf := meta.(*providerConfiguration)
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	if vmType, vmId, name, err = parseVmSnapshotId(d.Id()); err != nil {
+		goto End
+	}
+	if vm, err = vmSnapshotGuest(vmType, vmId); err != nil {
+		goto End
+	}
+
+	if d.HasChange("description") {
+		if _, err = apiPut(fmt.Sprintf("%s/snapshot/%s/config", vmApiPath(vm), name), map[string]interface{}{
+			"description": d.Get("description").(string),
+		}); err != nil {
+			goto End
+		}
+	}
+
+	// the guest is left stopped, unless the snapshot includes the RAM
+	if d.HasChange("rollback_trigger") && d.Get("rollback_trigger").(string) != "" {
+		log.Printf("[DEBUG] rolling %s back to snapshot %s", resourceId(vm), name)
+		_, err = apiPost(fmt.Sprintf("%s/snapshot/%s/rollback", vmApiPath(vm), name), nil)
+	}
+
+End:
+	pmParallelEnd(pconf)
+
+	if err != nil {
+		return err
+	}
+
+	return resourceVmSnapshotRead(d, meta)
+}
+
+func resourceVmSnapshotDelete(d *schema.ResourceData, meta interface{}) (err error) {
+	var (
+		vmType, name string
+		vmId         int
+		vm           *pxapi.Vm
+	)
+
+	pconf := meta.(*providerConfiguration)
+	pmParallelBegin(pconf)
+	pconf.Client.Set()
+
+	if vmType, vmId, name, err = parseVmSnapshotId(d.Id()); err != nil {
+		d.SetId("")
+		goto End
+	}
+	if vm, err = vmSnapshotGuest(vmType, vmId); err != nil {
+		goto End
+	}
+
+	_, err = apiDelete(fmt.Sprintf("%s/snapshot/%s", vmApiPath(vm), name), nil)
+
+End:
+	pmParallelEnd(pconf)
+	return
+}
+
+// look a guest up on the node it currently is, checking it's of the type the
+// snapshot was taken of
+func vmSnapshotGuest(vmType string, vmId int) (*pxapi.Vm, error) {
+	vm := pxapi.NewVm(vmId)
+	if err := vm.Check(); err != nil {
+		return nil, err
+	}
+	if vm.Type() != vmType {
+		return nil, fmt.Errorf("Guest %d is %s, not %s", vmId, vm.Type(), vmType)
+	}
+	return vm, nil
+}
+
+func parseVmSnapshotId(resId string) (vmType string, vmId int, name string, err error) {
+	idParts := strings.SplitN(resId, "/", 3)
+	if len(idParts) == 3 && (idParts[0] == "qemu" || idParts[0] == "lxc") {
+		if vmId, err = strconv.Atoi(idParts[1]); err == nil {
+			return idParts[0], vmId, idParts[2], nil
+		}
+	}
+	return "", -1, "", fmt.Errorf("Invalid resource format: %s. Must be type/vmId/name", resId)
+}
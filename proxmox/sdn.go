@@ -0,0 +1,20 @@
+package proxmox
+
+import (
+	"log"
+	"regexp"
+)
+
+// SDN zones, vnets and subnets are written to the pending SDN config of the
+// cluster, and applied to every node right away like the node networking
+// changes are. VNets show up on the nodes as bridges of the same name.
+
+// zone and vnet ids are short lowercase names
+var rxSdnId = regexp.MustCompile("^[a-z][a-z0-9]{0,7}$")
+
+// reload the network of every node with the pending SDN config
+func sdnApply() error {
+	log.Print("[DEBUG] applying SDN config")
+	_, err := apiPut("/cluster/sdn", nil)
+	return err
+}
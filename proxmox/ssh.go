@@ -0,0 +1,118 @@
+package proxmox
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// Access to the files of a node over ssh, for what the api can't do, like
+// writing snippets. The node is reached at the address it has in the cluster
+// status, with the pm_ssh_* credentials of the provider.
+
+func nodeSshClient(pconf *providerConfiguration, node string) (*ssh.Client, error) {
+	var auth []ssh.AuthMethod
+
+	if pconf.SshPrivateKey != "" {
+		signer, err := ssh.ParsePrivateKey([]byte(pconf.SshPrivateKey))
+		if err != nil {
+			return nil, fmt.Errorf("Invalid pm_ssh_private_key: %v", err)
+		}
+		auth = append(auth, ssh.PublicKeys(signer))
+	}
+	if pconf.SshPassword != "" {
+		auth = append(auth, ssh.Password(pconf.SshPassword))
+	}
+	if len(auth) == 0 {
+		return nil, fmt.Errorf("Either pm_ssh_private_key or pm_ssh_password must be set to write files on node %s", node)
+	}
+
+	member, err := apiFindInList("/cluster/status", "name", node)
+	if err != nil {
+		return nil, err
+	}
+	address, _ := member["ip"].(string)
+	if address == "" {
+		return nil, fmt.Errorf("No address found for node %s", node)
+	}
+
+	hostKeyCallback, err := nodeHostKeyCallback(pconf)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("[DEBUG] connecting to node %s at %s", node, address)
+
+	return ssh.Dial("tcp", net.JoinHostPort(address, "22"), &ssh.ClientConfig{
+		User:            pconf.SshUser,
+		Auth:            auth,
+		HostKeyCallback: hostKeyCallback,
+	})
+}
+
+// host keys are checked against pm_ssh_known_hosts, by default the
+// known_hosts of the user, unless pm_tls_insecure leaves the nodes unchecked
+// as it does for the api
+func nodeHostKeyCallback(pconf *providerConfiguration) (ssh.HostKeyCallback, error) {
+	if pconf.TlsInsecure {
+		return ssh.InsecureIgnoreHostKey(), nil
+	}
+
+	knownHosts := pconf.SshKnownHosts
+	if knownHosts == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		knownHosts = filepath.Join(home, ".ssh", "known_hosts")
+	}
+
+	hostKeyCallback, err := knownhosts.New(knownHosts)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading pm_ssh_known_hosts: %v", err)
+	}
+	return hostKeyCallback, nil
+}
+
+// run a command on a node, feeding it stdin
+func nodeRun(pconf *providerConfiguration, node string, command string, stdin string) error {
+	client, err := nodeSshClient(pconf, node)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	session, err := client.NewSession()
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	var stderr bytes.Buffer
+	session.Stdin = strings.NewReader(stdin)
+	session.Stderr = &stderr
+
+	if err = session.Run(command); err != nil {
+		return fmt.Errorf("Error running %s on node %s: %v %s", command, node, err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+func nodeWriteFile(pconf *providerConfiguration, node string, path string, content string) error {
+	return nodeRun(pconf, node, fmt.Sprintf("cat > %s", shellQuote(path)), content)
+}
+
+func nodeRemoveFile(pconf *providerConfiguration, node string, path string) error {
+	return nodeRun(pconf, node, fmt.Sprintf("rm -f %s", shellQuote(path)), "")
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
@@ -2,9 +2,12 @@ package proxmox
 
 import (
 	"encoding/json"
+	"fmt"
 	pxapi "github.com/3coma3/proxmox-api-go/proxmox"
 	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+	"sort"
 	"strconv"
+	"strings"
 )
 
 // convert a schema.TypeSet such as net, mp or disk to map[int]map[string]interface{}
@@ -88,3 +91,23 @@ func updateDeviceSet(
 	}
 	return updateDevicesSet(terraDevicesSet, nestApiDeviceMap)
 }
+
+// combine the failures of an operation done device by device into one error,
+// each entry being "device: error"
+func devicesError(action string, deviceErrors []string) error {
+	if len(deviceErrors) == 0 {
+		return nil
+	}
+	sort.Strings(deviceErrors)
+	return fmt.Errorf("Error %s:\n%s", action, strings.Join(deviceErrors, "\n"))
+}
+
+// return the storage part of a volume id such as local-lvm:vm-100-disk-0,
+// or an empty string for paths like the ones of bind mounts
+func volumeStorage(volume interface{}) string {
+	volumeParts := strings.SplitN(fmt.Sprint(volume), ":", 2)
+	if len(volumeParts) < 2 {
+		return ""
+	}
+	return volumeParts[0]
+}